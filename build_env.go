@@ -49,6 +49,10 @@ type BuildEnv struct {
 	// ${DistDir}/lib
 	DistLibDir string
 
+	// Separated debug symbols of release builds.
+	// SymbolsDir = ${BuildTypeDir}/dist/symbols/${SDK}/${Arch}
+	SymbolsDir string
+
 	// TmpDir = ${TargetDir}/tmp
 	TmpDir string
 
@@ -59,7 +63,7 @@ type BuildEnv struct {
 func NewBuildEnv(shell *Shell, env *OSEnv) *BuildEnv {
 	cliArgs := shell.Args
 
-	buildTypeDir := GetBuildTypeDir(cliArgs.DebugBuild)
+	buildTypeDir := GetProfileBuildTypeDir(cliArgs.DebugBuild, cliArgs.Profile)
 	sdkDir := GetSDKDir(buildTypeDir, env.SDK)
 	archDir := filepath.Join(sdkDir, string(env.Arch))
 	target := cliArgs.Target
@@ -96,6 +100,7 @@ func NewBuildEnv(shell *Shell, env *OSEnv) *BuildEnv {
 		OutDir:          outDir,
 		OutIncludeDir:   outIncludeDir,
 		OutLibDir:       outLibDir,
+		SymbolsDir:      GetSymbolsDir(buildTypeDir, env.SDK, env.Arch),
 		TmpDir:          tmpDir,
 		TmpBuildDir:     tmpBuildDir,
 		TmpCrossfileDir: tmpCrossfileDir,
@@ -128,10 +133,13 @@ func (be *BuildEnv) LogSummary() {
 }
 
 type VerifyFileOptions struct {
-	DistDir               bool
-	Dylib                 bool
-	SkipDarwinSDKVerCheck bool
-	DarwinSDKVer          string
+	DistDir                  bool
+	Dylib                    bool
+	SkipDarwinSDKVerCheck    bool
+	DarwinSDKVer             string
+	SkipAndroidPageSizeCheck bool
+	// If set, exported symbols of the file are checked against this policy.
+	SymbolPolicy *SymbolPolicy
 }
 
 func (be *BuildEnv) VerifyFile(outFile string, opt *VerifyFileOptions) {
@@ -160,6 +168,17 @@ func (be *BuildEnv) VerifyFile(outFile string, opt *VerifyFileOptions) {
 		}
 		e.VerifyDarwinStaticLibSDK(filePath, minSDKVer, e.SDK)
 	}
+
+	// Verify page alignment for Android shared libs.
+	if !opt.SkipAndroidPageSizeCheck && e.IsAndroidPlatform() && libType == LibTypeDynamic {
+		if pageSize := be.CLIArgs.GetAndroidMaxPageSize(); pageSize > 0 {
+			e.VerifyAndroidLibPageAlignment(filePath, uint64(pageSize))
+		}
+	}
+
+	if opt.SymbolPolicy != nil {
+		e.VerifyExportedSymbols(filePath, opt.SymbolPolicy)
+	}
 }
 
 func (be *BuildEnv) getVerifyFilePath(outFile string, opt *VerifyFileOptions) string {
@@ -179,3 +198,11 @@ func (be *BuildEnv) getVerifyFilePath(outFile string, opt *VerifyFileOptions) st
 	}
 	return s + ".a"
 }
+
+func (be *BuildEnv) getStaticLibPath(lib string) string {
+	libPath := filepath.Join(be.OutLibDir, lib+".a")
+	if !io2.FileExists(libPath) {
+		be.Shell.Quit("Static lib not found: " + libPath)
+	}
+	return libPath
+}
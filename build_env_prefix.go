@@ -0,0 +1,146 @@
+package ku
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/mgenware/j9/v3"
+	"github.com/mgenware/ku-builder/io2"
+	"github.com/mgenware/ku-builder/objfile"
+)
+
+var cIdentifierRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+var nonCIdentifierCharRegex = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+type PrefixSymbolsOptions struct {
+	// Required. Example: ku_
+	Prefix string
+	// Required. Static libs in OutLibDir (without extension) whose exported symbols are renamed.
+	// Example: libpng, libz
+	DepLibs []string
+	// Optional patterns (`SymbolPolicy` syntax) selecting symbols of `DepLibs` to rename. Default: all C symbols.
+	Symbols []string
+	// Other static libs in OutLibDir that reference symbols of `DepLibs` and were built before the shim header existed.
+	// Their references are renamed too.
+	ReferencingLibs []string
+
+	// Required. Shim header written to OutIncludeDir mapping original names to prefixed names.
+	// Example: ku_png_prefix.h
+	ShimHeader string
+	// Installed headers of `DepLibs` (relative to OutIncludeDir), the shim header is included at the top of each.
+	// Example: libpng16/png.h
+	Headers []string
+}
+
+// Renames exported C symbols of the selected static libs with a prefix to avoid duplicate symbols when apps link
+// multiple SDKs vendoring the same dependency. Should be called after installing all the libs.
+// C++ (mangled) symbols are not renamed since they cannot be mapped by the shim header.
+func (be *BuildEnv) PrefixSymbols(opt *PrefixSymbolsOptions) {
+	shell := be.Shell
+	if opt == nil || opt.Prefix == "" || len(opt.DepLibs) == 0 || opt.ShimHeader == "" {
+		shell.Quit("PrefixSymbols: Prefix, DepLibs and ShimHeader are required")
+	}
+	e := be.OSEnv
+
+	var symbols []string
+	seen := make(map[string]bool)
+	for _, lib := range opt.DepLibs {
+		libPath := be.getStaticLibPath(lib)
+		libSymbols, err := objfile.ReadExportedSymbols(libPath)
+		if err != nil {
+			shell.Quit(fmt.Sprintf("Error reading exported symbols: %v", err))
+		}
+		for _, sym := range libSymbols {
+			if seen[sym] || !cIdentifierRegex.MatchString(sym) || strings.HasPrefix(sym, "_Z") || strings.HasPrefix(sym, opt.Prefix) {
+				continue
+			}
+			if len(opt.Symbols) > 0 {
+				matched, err := matchAnyPattern(opt.Symbols, sym)
+				if err != nil {
+					shell.Quit(err.Error())
+				}
+				if matched == "" {
+					continue
+				}
+			}
+			seen[sym] = true
+			symbols = append(symbols, sym)
+		}
+	}
+	if len(symbols) == 0 {
+		shell.Log(j9.LogLevelWarning, fmt.Sprintf("No symbols to prefix in %v", opt.DepLibs))
+		return
+	}
+
+	// Mach-O symbols have a leading underscore.
+	rawPrefix := ""
+	if e.IsDarwinPlatform() {
+		rawPrefix = "_"
+	}
+	var redefineLines strings.Builder
+	for _, sym := range symbols {
+		redefineLines.WriteString(rawPrefix + sym + " " + rawPrefix + opt.Prefix + sym + "\n")
+	}
+	io2.Mkdirp(be.TmpDir)
+	// `ShimHeader` may contain dirs, e.g. `foo/prefix.h` -> `redefine-syms-foo_prefix.txt`.
+	shimName := nonCIdentifierCharRegex.ReplaceAllString(strings.TrimSuffix(opt.ShimHeader, filepath.Ext(opt.ShimHeader)), "_")
+	redefineFile := filepath.Join(be.TmpDir, "redefine-syms-"+shimName+".txt")
+	if err := os.WriteFile(redefineFile, []byte(redefineLines.String()), 0644); err != nil {
+		shell.Quit(fmt.Sprintf("Error writing %s: %v", redefineFile, err))
+	}
+
+	objcopy := e.GetLLVMObjcopyPath()
+	for _, lib := range append(append([]string{}, opt.DepLibs...), opt.ReferencingLibs...) {
+		shell.Spawn(&j9.SpawnOpt{
+			Name: objcopy,
+			Args: []string{"--redefine-syms=" + redefineFile, be.getStaticLibPath(lib)},
+		})
+	}
+
+	shimPath := filepath.Join(be.OutIncludeDir, opt.ShimHeader)
+	io2.Mkdirp(filepath.Dir(shimPath))
+	if err := os.WriteFile(shimPath, []byte(symbolPrefixShimContent(opt.ShimHeader, opt.Prefix, symbols)), 0644); err != nil {
+		shell.Quit(fmt.Sprintf("Error writing %s: %v", shimPath, err))
+	}
+	for _, header := range opt.Headers {
+		be.includeShimHeader(filepath.Join(be.OutIncludeDir, header), shimPath)
+	}
+	shell.Log(j9.LogLevelSuccess, fmt.Sprintf("✅ Prefixed %d symbol(s) of %v with %s", len(symbols), opt.DepLibs, opt.Prefix))
+}
+
+// Adds `#include "<shim>"` to the top of the header. No-op if already included.
+func (be *BuildEnv) includeShimHeader(header, shimPath string) {
+	shell := be.Shell
+	data, err := os.ReadFile(header)
+	if err != nil {
+		shell.Quit(fmt.Sprintf("Error reading header %s: %v", header, err))
+	}
+	rel, err := filepath.Rel(filepath.Dir(header), shimPath)
+	if err != nil {
+		shell.Quit(fmt.Sprintf("Error resolving shim header path: %v", err))
+	}
+	includeLine := "#include \"" + rel + "\"\n"
+	if strings.Contains(string(data), includeLine) {
+		return
+	}
+	if err := os.WriteFile(header, append([]byte(includeLine), data...), 0644); err != nil {
+		shell.Quit(fmt.Sprintf("Error writing header %s: %v", header, err))
+	}
+}
+
+func symbolPrefixShimContent(headerName, prefix string, symbols []string) string {
+	guard := strings.ToUpper(nonCIdentifierCharRegex.ReplaceAllString(headerName, "_"))
+
+	var sb strings.Builder
+	sb.WriteString("// Generated by ku-builder. DO NOT EDIT.\n")
+	sb.WriteString("#ifndef " + guard + "\n")
+	sb.WriteString("#define " + guard + "\n\n")
+	for _, sym := range symbols {
+		sb.WriteString("#define " + sym + " " + prefix + sym + "\n")
+	}
+	sb.WriteString("\n#endif // " + guard + "\n")
+	return sb.String()
+}
@@ -0,0 +1,16 @@
+package ku
+
+import "testing"
+
+func TestSymbolPrefixShimContent(t *testing.T) {
+	got := symbolPrefixShimContent("foo/ku-png_prefix.h", "ku_", []string{"png_create_read_struct", "png_init_io"})
+	want := "// Generated by ku-builder. DO NOT EDIT.\n" +
+		"#ifndef FOO_KU_PNG_PREFIX_H\n" +
+		"#define FOO_KU_PNG_PREFIX_H\n\n" +
+		"#define png_create_read_struct ku_png_create_read_struct\n" +
+		"#define png_init_io ku_png_init_io\n" +
+		"\n#endif // FOO_KU_PNG_PREFIX_H\n"
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
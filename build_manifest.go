@@ -0,0 +1,82 @@
+package ku
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/mgenware/ku-builder/io2"
+)
+
+// Describes how the outputs of a target were built. Written after install and read by
+// deployment to reject outputs not meant for release (e.g. sanitizer builds).
+type BuildManifest struct {
+	Target  string `json:"target"`
+	Debug   bool   `json:"debug"`
+	Profile string `json:"profile,omitempty"`
+	// Sanitizer runtime name, see `BuildProfile.Sanitizer`.
+	Sanitizer string `json:"sanitizer,omitempty"`
+	// Sources of libs built for the target. K: repo name.
+	Repos map[string]*BuildManifestRepo `json:"repos,omitempty"`
+}
+
+type BuildManifestRepo struct {
+	Url    string `json:"url,omitempty"`
+	Commit string `json:"commit,omitempty"`
+	// K: submodule path, V: commit.
+	Submodules map[string]string `json:"submodules,omitempty"`
+}
+
+// Returns nil if the manifest doesn't exist.
+func ReadBuildManifest(buildTypeDir string, target string) (*BuildManifest, error) {
+	path := GetBuildManifestPath(buildTypeDir, target)
+	if !io2.FileExists(path) {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var manifest BuildManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("error parsing build manifest %s: %w", path, err)
+	}
+	return &manifest, nil
+}
+
+// Called after installing each lib of the target, the repo of the lib is merged into the existing manifest.
+func (bp *Builder) writeBuildManifest() {
+	be := bp.BuildEnv
+	cliArgs := be.CLIArgs
+	repos := make(map[string]*BuildManifestRepo)
+	if old, err := ReadBuildManifest(be.BuildTypeDir, be.Target); err == nil && old != nil && old.Repos != nil {
+		repos = old.Repos
+	}
+	commit, submodules := bp.getRepoCommits()
+	repos[bp.Repo.Name] = &BuildManifestRepo{
+		Url:        bp.Repo.Url,
+		Commit:     commit,
+		Submodules: submodules,
+	}
+
+	manifest := &BuildManifest{
+		Target:  be.Target,
+		Debug:   cliArgs.DebugBuild,
+		Profile: cliArgs.Profile,
+		Repos:   repos,
+	}
+	if profile := cliArgs.GetBuildProfile(); profile != nil {
+		manifest.Sanitizer = profile.Sanitizer
+	}
+
+	path := GetBuildManifestPath(be.BuildTypeDir, be.Target)
+	io2.Mkdirp(filepath.Dir(path))
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		be.Shell.Quit(fmt.Sprintf("Error encoding build manifest: %v", err))
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		be.Shell.Quit(fmt.Sprintf("Error writing build manifest %s: %v", path, err))
+	}
+}
@@ -66,6 +66,10 @@ func (bp *Builder) GetKuBuiltinEnv(setup bool) []string {
 		)
 	}
 
+	if bp.CLIArgs.Reproducible {
+		env = append(env, bp.getReproducibleEnv()...)
+	}
+
 	if setup {
 		env = append(env,
 			"PKG_CONFIG="+bp.OS.GetPkgConfigPath(),
@@ -0,0 +1,121 @@
+package ku
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mgenware/j9/v3"
+	"github.com/mgenware/ku-builder/io2"
+)
+
+// Cross-compile answers of configure tests that can't run on the build machine.
+var DefaultAutoconfConfigSite = []string{
+	"ac_cv_func_malloc_0_nonnull=yes",
+	"ac_cv_func_realloc_0_nonnull=yes",
+	"ac_cv_func_mmap_fixed_mapped=yes",
+}
+
+// Returns the autoconf triplet of the build machine, e.g. `arm64-apple-darwin24.1.0`.
+// The triplet differs from `GetAutoconfHost` so configure always runs in cross-compile mode.
+func (e *OSEnv) GetAutoconfBuild() string {
+	return globalCachedString("autoconf-build", func() string {
+		machine := e.shell.ShellCached("uname -m")
+		if e.shell.ShellCached("uname -s") == "Darwin" {
+			return machine + "-apple-darwin" + e.shell.ShellCached("uname -r")
+		}
+		return machine + "-pc-linux-gnu"
+	})
+}
+
+// Generates `configure` from `configure.ac` if needed. Uses `autogen.sh` if present, otherwise `autoreconf -fi`.
+func (bp *Builder) bootstrapAutotools(srcDir string) {
+	if io2.FileExists(filepath.Join(srcDir, "configure")) {
+		return
+	}
+	if !io2.FileExists(filepath.Join(srcDir, "configure.ac")) && !io2.FileExists(filepath.Join(srcDir, "configure.in")) {
+		bp.Shell.Quit(fmt.Sprintf("Neither configure nor configure.ac found in %s", srcDir))
+	}
+
+	bp.Shell.Log(j9.LogLevelInfo, "Generating configure in "+srcDir)
+	autogen := filepath.Join(srcDir, "autogen.sh")
+	if io2.FileExists(autogen) {
+		bp.Shell.Spawn(&j9.SpawnOpt{
+			Name:       "sh",
+			Args:       []string{autogen},
+			WorkingDir: srcDir,
+			// Most autogen.sh scripts run configure unless NOCONFIGURE is set.
+			Env: []string{"NOCONFIGURE=1"},
+		})
+	} else {
+		bp.Shell.Spawn(&j9.SpawnOpt{
+			Name:       "autoreconf",
+			Args:       []string{"-fi"},
+			WorkingDir: srcDir,
+		})
+	}
+	if !io2.FileExists(filepath.Join(srcDir, "configure")) {
+		bp.Shell.Quit(fmt.Sprintf("configure was not generated in %s", srcDir))
+	}
+}
+
+func isAutoconfScript(path string) bool {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	return strings.Contains(string(data), "Generated by GNU Autoconf")
+}
+
+// Returns `--host`, `--build`, `--prefix`, static/shared and sysroot args of autoconf configure scripts.
+// Args already in `userArgs` are skipped.
+func (bp *Builder) getAutoconfArgs(userArgs []string) []string {
+	e := bp.OS
+	hasArg := func(names ...string) bool {
+		for _, arg := range userArgs {
+			name, _, _ := strings.Cut(arg, "=")
+			for _, n := range names {
+				if name == n {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	var args []string
+	if !hasArg("--host") {
+		args = append(args, "--host="+e.MustGetAutoconfHost())
+	}
+	if !hasArg("--build") {
+		args = append(args, "--build="+e.GetAutoconfBuild())
+	}
+	if !hasArg("--prefix") {
+		args = append(args, "--prefix="+bp.BuildEnv.OutDir)
+	}
+	if !hasArg("--enable-static", "--disable-static", "--enable-shared", "--disable-shared") {
+		if bp.LibType == LibTypeDynamic {
+			args = append(args, "--enable-shared", "--disable-static")
+		} else {
+			args = append(args, "--enable-static", "--disable-shared")
+		}
+	}
+	if !hasArg("--with-sysroot") {
+		args = append(args, "--with-sysroot="+e.GetSDKRootPath())
+	}
+	return args
+}
+
+// Writes `config.site` of the current SDK/arch and returns its path.
+func (bp *Builder) writeAutoconfConfigSite(extra []string) string {
+	path := filepath.Join(bp.BuildEnv.TmpDir, "config.site")
+	lines := []string{"# Generated by ku-builder for " + bp.OS.GetSDKArchString()}
+	lines = append(lines, DefaultAutoconfConfigSite...)
+	lines = append(lines, extra...)
+	io2.Mkdirp(filepath.Dir(path))
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		bp.Shell.Quit(fmt.Sprintf("Error writing %s: %v", path, err))
+	}
+	return path
+}
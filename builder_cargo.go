@@ -0,0 +1,177 @@
+package ku
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/mgenware/j9/v3"
+)
+
+// Returns the Rust target triple, e.g. `aarch64-apple-ios-sim`.
+func (e *OSEnv) GetRustTargetTriple() string {
+	arch := GetOldArch(e.Arch)
+	switch e.SDK {
+	case SDKMacos:
+		return arch + "-apple-darwin"
+	case SDKIos:
+		if e.Arch == ArchArm64 {
+			return arch + "-apple-ios"
+		}
+	case SDKIosSimulator:
+		if e.Arch == ArchArm64 {
+			return arch + "-apple-ios-sim"
+		}
+		return arch + "-apple-ios"
+	case SDKAndroid:
+		return arch + "-linux-android"
+	}
+	e.ThrowUnsupportedError()
+	panic("unreachable")
+}
+
+// Returns the cargo profile, defaults to `dev` for debug builds and `release` otherwise.
+func (bp *Builder) getCargoProfile(profile string) string {
+	if profile != "" {
+		return profile
+	}
+	if bp.CLIArgs.DebugBuild {
+		return "dev"
+	}
+	return "release"
+}
+
+// Returns the dir of the built artifacts, e.g. `<build dir>/aarch64-linux-android/release`.
+func (bp *Builder) GetCargoArtifactDir(profile string) string {
+	profile = bp.getCargoProfile(profile)
+	// `dev` outputs to `debug`.
+	if profile == "dev" {
+		profile = "debug"
+	}
+	return filepath.Join(bp.mustGetBuildDir(false), bp.OS.GetRustTargetTriple(), profile)
+}
+
+// Returns env of cargo and the `cc` crate: target linker, rustflags, and `CC_<triple>`, `AR_<triple>`, etc.
+// `libName` is the crate lib name, used to set the soname or install name of dynamic libs.
+func (bp *Builder) GetCargoEnv(libName string, profile string) []string {
+	e := bp.OS
+	triple := e.GetRustTargetTriple()
+	cargoTriple := strings.ToUpper(strings.ReplaceAll(triple, "-", "_"))
+	ccTriple := strings.ReplaceAll(triple, "-", "_")
+
+	var ar string
+	var linkArgs []string
+	var env []string
+	if e.IsAndroidPlatform() {
+		ar = e.GetNDKToolchainBinPath("llvm-ar")
+		linkArgs = bp.getAndroidLDFlags()
+		if bp.LibType == LibTypeDynamic {
+			linkArgs = append(linkArgs, "-Wl,-soname,lib"+libName+".so")
+		}
+	} else {
+		ar = e.RunXcodeFindCached("ar")
+		if bp.LibType == LibTypeDynamic {
+			// Defaults to the absolute path of the artifact.
+			linkArgs = append(linkArgs, "-Wl,-install_name,@rpath/lib"+libName+".dylib")
+		}
+		env = append(env, "SDKROOT="+e.GetSDKRootPath())
+		if e.SDK == SDKMacos {
+			env = append(env, "MACOSX_DEPLOYMENT_TARGET="+MinMacosVersion)
+		} else {
+			env = append(env, "IPHONEOS_DEPLOYMENT_TARGET="+MinIosVersion)
+		}
+	}
+
+	var rustflags []string
+	for _, arg := range linkArgs {
+		rustflags = append(rustflags, "-C", "link-arg="+arg)
+	}
+	if bp.CLIArgs.Reproducible {
+		for _, pair := range bp.getReproduciblePathMap() {
+			rustflags = append(rustflags, "--remap-path-prefix="+pair[0]+"="+pair[1])
+		}
+	}
+
+	cflags := bp.GetCompilerFlagsString(nil)
+	env = append(env,
+		"CARGO_TARGET_DIR="+bp.mustGetBuildDir(false),
+		"CARGO_TARGET_"+cargoTriple+"_LINKER="+e.GetCCPath(),
+		"CARGO_TARGET_"+cargoTriple+"_RUSTFLAGS="+strings.Join(rustflags, " "),
+		"CC_"+ccTriple+"="+e.GetCCPath(),
+		"CXX_"+ccTriple+"="+e.GetCXXPath(),
+		"AR_"+ccTriple+"="+ar,
+		"CFLAGS_"+ccTriple+"="+cflags,
+		"CXXFLAGS_"+ccTriple+"="+cflags,
+	)
+	if bp.shouldSplitDebugSymbols() {
+		env = append(env, "CARGO_PROFILE_"+strings.ToUpper(strings.ReplaceAll(bp.getCargoProfile(profile), "-", "_"))+"_DEBUG=true")
+	}
+	return env
+}
+
+type cargoMetadata struct {
+	Packages []struct {
+		Name         string `json:"name"`
+		ManifestPath string `json:"manifest_path"`
+		Targets      []struct {
+			Name string   `json:"name"`
+			Kind []string `json:"kind"`
+		} `json:"targets"`
+	} `json:"packages"`
+}
+
+// Returns the lib name (e.g. `foo_ffi`) and the dir of the crate at `manifestPath`,
+// or of the workspace member `pkg` if set.
+func (bp *Builder) getCargoLib(manifestPath string, pkg string) (string, string) {
+	output := bp.Shell.Shell("cargo metadata --no-deps --format-version 1 --manifest-path \"" + manifestPath + "\"")
+	// Output may start with cargo warnings, metadata is the last line.
+	if i := strings.LastIndex(output, "\n"); i >= 0 {
+		output = output[i+1:]
+	}
+	var metadata cargoMetadata
+	if err := json.Unmarshal([]byte(output), &metadata); err != nil {
+		bp.Shell.Quit(fmt.Sprintf("Error parsing cargo metadata of %s: %v", manifestPath, err))
+	}
+	// Paths in metadata are canonical.
+	if realPath, err := filepath.EvalSymlinks(manifestPath); err == nil {
+		manifestPath = realPath
+	}
+	for _, p := range metadata.Packages {
+		if (pkg != "" && p.Name != pkg) || (pkg == "" && p.ManifestPath != manifestPath) {
+			continue
+		}
+		for _, t := range p.Targets {
+			if slices.ContainsFunc(t.Kind, func(kind string) bool {
+				return kind == "lib" || kind == "rlib" || kind == "staticlib" || kind == "cdylib"
+			}) {
+				return strings.ReplaceAll(t.Name, "-", "_"), filepath.Dir(p.ManifestPath)
+			}
+		}
+		bp.Shell.Quit(fmt.Sprintf("Package %s has no lib target", p.Name))
+	}
+	bp.Shell.Quit(fmt.Sprintf("Package %q not found in %s", pkg, manifestPath))
+	panic("unreachable")
+}
+
+// Quits if the Rust target is not installed (rustup only).
+func (bp *Builder) checkRustTarget() {
+	output, err := bp.Shell.Tunnel.ShellRaw(&j9.ShellOpt{Cmd: "rustup target list --installed"})
+	if err != nil {
+		return
+	}
+	triple := bp.OS.GetRustTargetTriple()
+	if !slices.Contains(strings.Fields(output), triple) {
+		bp.Shell.Quit(fmt.Sprintf("Rust target %s is not installed. Run `rustup target add %s`.", triple, triple))
+	}
+}
+
+// Generates a C header of the crate at `crateDir` into `OutIncludeDir` with cbindgen.
+// `cbindgen.toml` of the crate is used if present.
+func (bp *Builder) RunCbindgen(crateDir string, header string) {
+	bp.Shell.Spawn(&j9.SpawnOpt{
+		Name: "cbindgen",
+		Args: []string{"--quiet", "--output", filepath.Join(bp.BuildEnv.OutIncludeDir, header), crateDir},
+	})
+}
@@ -0,0 +1,157 @@
+package ku
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/mgenware/j9/v3"
+	"github.com/mgenware/ku-builder/io2"
+)
+
+// Returns the commit the repo must be checked out at: `RepoInfo.Commit` or the commit pinned in `ku.lock`.
+func (bp *Builder) getPinnedCommit() string {
+	if bp.Repo.Commit != "" {
+		return bp.Repo.Commit
+	}
+	if lockEntry := bp.getLockEntry(); lockEntry != nil {
+		return lockEntry.Commit
+	}
+	return ""
+}
+
+// Returns an empty string if HEAD doesn't exist (e.g. a new repo).
+func gitHead(shell *Shell, repoDir string) string {
+	output, err := shell.Tunnel.ShellRaw(&j9.ShellOpt{Cmd: "git -C \"" + repoDir + "\" rev-parse -q --verify HEAD"})
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(output)
+}
+
+func gitHasCommit(shell *Shell, repoDir string, commit string) bool {
+	err := shell.SpawnRaw(&j9.SpawnOpt{
+		Name: "git",
+		Args: []string{"-C", repoDir, "cat-file", "-e", commit + "^{commit}"},
+	})
+	return err == nil
+}
+
+// `commit` can be abbreviated.
+func isSameCommit(head string, commit string) bool {
+	return head != "" && strings.HasPrefix(head, commit)
+}
+
+// Quits if tracked files of the repo have local changes, which would be lost or carried over by a checkout.
+// Untracked files (e.g. in-source build outputs) are ignored.
+func (bp *Builder) ensureCleanTree(repoDir string, reason string) {
+	output := bp.Shell.Shell("git -C \"" + repoDir + "\" status --porcelain --untracked-files=no --ignore-submodules=all")
+	if output == "" {
+		return
+	}
+	bp.Shell.Quit(fmt.Sprintf("%s, but %s has local changes:\n%s\nCommit or discard the changes, or delete the dir.", reason, repoDir, output))
+}
+
+// Fetches only the commit if the server allows it, otherwise fetches the full history.
+func (bp *Builder) fetchCommit(repoDir string, commit string) {
+	shell := bp.Shell
+	if gitHasCommit(shell, repoDir, commit) {
+		return
+	}
+	fetchUrl := bp.resolveRepoFetchUrl()
+	err := shell.SpawnRaw(&j9.SpawnOpt{
+		Name: "git",
+		Args: []string{"-C", repoDir, "fetch", "--depth", "1", fetchUrl, commit},
+	})
+	if err == nil {
+		return
+	}
+	// Abbreviated commits can't be fetched directly, and some servers don't allow fetching commits by SHA.
+	shell.Log(j9.LogLevelWarning, fmt.Sprintf("Shallow fetch of %s failed, fetching the full history", commit))
+	args := []string{"-C", repoDir, "fetch", fetchUrl, "+refs/heads/*:refs/remotes/origin/*", "+refs/tags/*:refs/tags/*"}
+	if io2.FileExists(filepath.Join(repoDir, ".git", "shallow")) {
+		args = append(args, "--unshallow")
+	}
+	shell.Spawn(&j9.SpawnOpt{Name: "git", Args: args})
+}
+
+// Checks out the commit if HEAD is different. Returns true if HEAD changed.
+func (bp *Builder) checkoutCommit(repoDir string, commit string) bool {
+	head := gitHead(bp.Shell, repoDir)
+	if isSameCommit(head, commit) {
+		return false
+	}
+	if head != "" {
+		bp.ensureCleanTree(repoDir, fmt.Sprintf("%s must be checked out at %s (HEAD is %s)", bp.Repo.Name, commit, head))
+	}
+	bp.fetchCommit(repoDir, commit)
+	bp.Shell.Spawn(&j9.SpawnOpt{
+		Name: "git",
+		Args: []string{"-C", repoDir, "checkout", "--detach", commit},
+	})
+	return true
+}
+
+// Checks out the tag if HEAD is different. Returns true if HEAD changed.
+func (bp *Builder) checkoutTag(repoDir string, tag string) bool {
+	shell := bp.Shell
+	tagRef := "refs/tags/" + tag
+	resolveTag := func() string {
+		output, err := shell.Tunnel.ShellRaw(&j9.ShellOpt{Cmd: "git -C \"" + repoDir + "\" rev-parse -q --verify \"" + tagRef + "^{commit}\""})
+		if err != nil {
+			return ""
+		}
+		return strings.TrimSpace(output)
+	}
+	commit := resolveTag()
+	if commit == "" {
+		shell.Spawn(&j9.SpawnOpt{
+			Name: "git",
+			Args: []string{"-C", repoDir, "fetch", "--depth", "1", bp.resolveRepoFetchUrl(), "+" + tagRef + ":" + tagRef},
+		})
+		commit = resolveTag()
+		if commit == "" {
+			shell.Quit(fmt.Sprintf("Tag %s not found in %s", tag, bp.Repo.Url))
+		}
+	}
+	return bp.checkoutCommit(repoDir, commit)
+}
+
+// Fetches the latest revision of the branch (or the default branch) and checks it out.
+func (bp *Builder) checkoutLatest(repoDir string) {
+	ref := bp.Repo.Branch
+	if ref == "" {
+		ref = "HEAD"
+	}
+	bp.Shell.Spawn(&j9.SpawnOpt{
+		Name: "git",
+		Args: []string{"-C", repoDir, "fetch", "origin", ref},
+	})
+	latest := strings.TrimSpace(bp.Shell.Shell("git -C \"" + repoDir + "\" rev-parse FETCH_HEAD"))
+	bp.checkoutCommit(repoDir, latest)
+}
+
+// Points `origin` to `RepoInfo.Url` if it has changed (e.g. the repo was cloned from a mirror).
+func (bp *Builder) updateRemoteUrl(repoDir string) {
+	if bp.CLIArgs.Offline {
+		return
+	}
+	output, err := bp.Shell.Tunnel.ShellRaw(&j9.ShellOpt{Cmd: "git -C \"" + repoDir + "\" remote get-url origin"})
+	if err == nil && strings.TrimSpace(output) == bp.Repo.Url {
+		return
+	}
+	args := []string{"-C", repoDir, "remote", "set-url", "origin", bp.Repo.Url}
+	if err != nil {
+		args[3] = "add"
+	}
+	bp.Shell.Spawn(&j9.SpawnOpt{Name: "git", Args: args})
+}
+
+func (bp *Builder) runPostCheckoutCommands() {
+	for _, cmd := range bp.Repo.PostCheckoutCommands {
+		bp.Shell.Spawn(&j9.SpawnOpt{
+			Name: cmd[0],
+			Args: cmd[1:],
+		})
+	}
+}
@@ -2,11 +2,36 @@ package ku
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"runtime"
+	"slices"
+	"strings"
 
 	"github.com/mgenware/j9/v3"
 )
 
+type CmakeGeneratorEnum string
+
+const (
+	CmakeGeneratorUnixMakefiles    CmakeGeneratorEnum = "Unix Makefiles"
+	CmakeGeneratorNinja            CmakeGeneratorEnum = "Ninja"
+	CmakeGeneratorNinjaMultiConfig CmakeGeneratorEnum = "Ninja Multi-Config"
+	CmakeGeneratorXcode            CmakeGeneratorEnum = "Xcode"
+)
+
+var SupportedCmakeGenerators = map[CmakeGeneratorEnum]bool{
+	CmakeGeneratorUnixMakefiles:    true,
+	CmakeGeneratorNinja:            true,
+	CmakeGeneratorNinjaMultiConfig: true,
+	CmakeGeneratorXcode:            true,
+}
+
+// Multi-config generators select the build type at build time via `--config`.
+func (g CmakeGeneratorEnum) IsMultiConfig() bool {
+	return g == CmakeGeneratorNinjaMultiConfig || g == CmakeGeneratorXcode
+}
+
 type RunCmakeGenOptions struct {
 	Args []string
 	Env  []string
@@ -15,15 +40,19 @@ type RunCmakeGenOptions struct {
 func (bp *Builder) RunCmakeGen(opt *RunCmakeGenOptions) {
 	bp.NotNullOrQuit(opt, "opt")
 
+	args := mergeCmakeFlagsArgs(opt.Args)
 	// Note: `opt.Env` should come at last to allow overriding builtin env if needed.
 	env := append(bp.GetKuBuiltinEnv(true), opt.Env...)
+	if bp.CLIArgs.CmakePresets {
+		bp.writeCmakeUserPresets(args, env)
+	}
 	env = append(env,
 		"KU_CMAKE_ACTION=gen",
 	)
 
 	bp.Shell.Spawn(&j9.SpawnOpt{
 		Name: "cmake",
-		Args: opt.Args,
+		Args: args,
 		Env:  env,
 	})
 }
@@ -59,17 +88,13 @@ func (bp *Builder) RunCmakeBuildOrInstall(opt *RunCmakeBuildOrInstallOptions, ou
 		args = append(args, "--target", opt.Target)
 	}
 
-	var config string
-	cliArgs := bp.Shell.Args
-	if cliArgs.DebugBuild {
-		config = "Debug"
-	} else {
-		config = "Release"
+	// Single-config generators use `CMAKE_BUILD_TYPE` set during generation.
+	if bp.GetCmakeBuildDirGenerator().IsMultiConfig() {
+		args = append(args, "--config", bp.getCmakeBuildType())
 	}
-	args = append(args, "--config", config)
 
 	// Strip during production install.
-	if opt.Action == CmakeActionInstall && !cliArgs.DebugBuild {
+	if opt.Action == CmakeActionInstall && bp.shouldStripOnInstall() {
 		// This uses `CMAKE_STRIP`, which is set by Android toolchain.
 		args = append(args, "--strip")
 	}
@@ -95,7 +120,11 @@ func (bp *Builder) RunCmakeBuildOrInstall(opt *RunCmakeBuildOrInstallOptions, ou
 		Env:  env,
 	})
 
-	bp.BuildEnv.VerifyFile(outFile, vfOpt)
+	if opt.Action == CmakeActionInstall {
+		bp.runPostInstall(outFile, vfOpt)
+	} else {
+		bp.BuildEnv.VerifyFile(outFile, vfOpt)
+	}
 }
 
 func (bp *Builder) RunCmakeBuild() {
@@ -121,6 +150,8 @@ type GetCmakeGenArgsOptions struct {
 	EnableSystemPath bool
 	Preset           string
 	CleanBuild       bool
+	// Defaults to `-cmake-generator`, then the preset's generator if `Preset` is set, otherwise Unix Makefiles.
+	Generator CmakeGeneratorEnum
 }
 
 func (bp *Builder) GetCmakeGenArgs() []string {
@@ -180,7 +211,6 @@ func (bp *Builder) GetCmakeGenArgsWithOptions(opt *GetCmakeGenArgsOptions) []str
 	}
 	args = append(args,
 		"-DBUILD_SHARED_LIBS="+isDylibStr,
-		"-DCMAKE_MAKE_PROGRAM="+osEnv.GetMakePath(),
 	)
 
 	if osEnv.IsDarwinPlatform() {
@@ -221,17 +251,37 @@ func (bp *Builder) GetCmakeGenArgsWithOptions(opt *GetCmakeGenArgsOptions) []str
 			"-DCMAKE_ANDROID_ARCH_ABI="+abi,
 			"-DCMAKE_SYSTEM_VERSION="+MinAndroidAPI,
 		)
+		if bp.useNDKFlexiblePageSizes() {
+			args = append(args, "-DANDROID_SUPPORT_FLEXIBLE_PAGE_SIZES=ON")
+		}
 	}
 
-	var buildType string
-	if cliArgs.DebugBuild {
-		buildType = "Debug"
-	} else {
-		buildType = "Release"
+	args = append(args, bp.getCmakeFlagsInitArgs()...)
+	if cliArgs.Reproducible {
+		if osEnv.IsAndroidPlatform() {
+			for _, lang := range []string{"C", "CXX"} {
+				args = append(args, "-DCMAKE_"+lang+"_ARCHIVE_CREATE=<CMAKE_AR> "+reproducibleArFlags+" <TARGET> <LINK_FLAGS> <OBJECTS>")
+			}
+		}
 	}
+
+	buildType := bp.getCmakeBuildType()
 	args = append(args, "-DCMAKE_BUILD_TYPE="+buildType)
+	args = append(args, bp.getCmakeProfileArgs(buildType)...)
+
+	buildDir := bp.mustGetBuildDir(opt.CleanBuild)
+	generator := bp.resolveCmakeGenerator(opt)
+	if generator != "" {
+		args = append(args, "-G", string(generator))
+		if makeProgram := bp.getCmakeMakeProgram(generator); makeProgram != "" {
+			args = append(args, "-DCMAKE_MAKE_PROGRAM="+makeProgram)
+		}
+		if generator.IsMultiConfig() {
+			args = append(args, "-DCMAKE_CONFIGURATION_TYPES="+buildType)
+		}
+	}
 
-	if cliArgs.CleanBuild || opt.CleanBuild {
+	if cliArgs.CleanBuild || opt.CleanBuild || bp.cmakeGeneratorChanged(generator) || bp.cmakeFlagsInitChanged(args) {
 		args = append(args, "--fresh")
 	}
 	if opt.Preset != "" {
@@ -240,11 +290,218 @@ func (bp *Builder) GetCmakeGenArgsWithOptions(opt *GetCmakeGenArgsOptions) []str
 
 	// Put source and build dir arguments at the end.
 	args = append(args, "-S", ".")
-	args = append(args, "-B", bp.mustGetBuildDir(opt.CleanBuild))
+	args = append(args, "-B", buildDir)
 
 	return args
 }
 
+func (bp *Builder) getCmakeBuildType() string {
+	if bp.CLIArgs.DebugBuild {
+		return "Debug"
+	}
+	return "Release"
+}
+
+// Returns an empty generator if it's left to the preset.
+func (bp *Builder) resolveCmakeGenerator(opt *GetCmakeGenArgsOptions) CmakeGeneratorEnum {
+	generator := opt.Generator
+	if generator == "" {
+		generator = bp.CLIArgs.CmakeGenerator
+	}
+	if generator == "" {
+		if opt.Preset != "" {
+			return ""
+		}
+		generator = CmakeGeneratorUnixMakefiles
+	}
+	if !SupportedCmakeGenerators[generator] {
+		bp.Shell.Quit(fmt.Sprintf("Unsupported CMake generator %q", generator))
+	}
+	if generator == CmakeGeneratorXcode && !bp.OS.IsDarwinPlatform() {
+		bp.Shell.Quit(fmt.Sprintf("CMake generator %s only supports Darwin SDKs", generator))
+	}
+	return generator
+}
+
+// Returns an empty string if the generator doesn't use `CMAKE_MAKE_PROGRAM` (Xcode).
+func (bp *Builder) getCmakeMakeProgram(generator CmakeGeneratorEnum) string {
+	switch generator {
+	case CmakeGeneratorUnixMakefiles:
+		return bp.OS.GetMakePath()
+	case CmakeGeneratorNinja, CmakeGeneratorNinjaMultiConfig:
+		return bp.OS.GetWhichExe("ninja")
+	}
+	return ""
+}
+
+// Returns entries (K: name without type) of CMakeCache.txt of the build dir, or nil if not generated.
+func (bp *Builder) readCmakeCache() map[string]string {
+	data, err := os.ReadFile(filepath.Join(bp.mustGetBuildDir(false), "CMakeCache.txt"))
+	if err != nil {
+		return nil
+	}
+	entries := make(map[string]string)
+	for _, line := range strings.Split(string(data), "\n") {
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "//") {
+			continue
+		}
+		// Example: `CMAKE_GENERATOR:INTERNAL=Ninja`.
+		if key, value, ok := strings.Cut(line, "="); ok {
+			name, _, _ := strings.Cut(key, ":")
+			entries[name] = strings.TrimSpace(value)
+		}
+	}
+	return entries
+}
+
+// Returns the generator recorded in CMakeCache.txt of the build dir, or an empty string if not generated.
+func (bp *Builder) GetCmakeBuildDirGenerator() CmakeGeneratorEnum {
+	return CmakeGeneratorEnum(bp.readCmakeCache()["CMAKE_GENERATOR"])
+}
+
+// CMake refuses to generate a build dir with another generator unless the cache is removed.
+func (bp *Builder) cmakeGeneratorChanged(generator CmakeGeneratorEnum) bool {
+	old := bp.GetCmakeBuildDirGenerator()
+	if generator == "" || old == "" || old == generator {
+		return false
+	}
+	bp.Shell.Log(j9.LogLevelInfo, fmt.Sprintf("CMake generator changed from %s to %s, regenerating %s", old, generator, bp.buildDir))
+	return true
+}
+
+// Removes `-G <generator>` from `args`. Returns the generator and the remaining args.
+func extractCmakeGeneratorArg(args []string) (CmakeGeneratorEnum, []string) {
+	var generator CmakeGeneratorEnum
+	var rest []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "-G" && i+1 < len(args) {
+			generator = CmakeGeneratorEnum(args[i+1])
+			i++
+		} else if value, ok := strings.CutPrefix(arg, "-G"); ok && value != "" {
+			generator = CmakeGeneratorEnum(value)
+		} else {
+			rest = append(rest, arg)
+		}
+	}
+	return generator, rest
+}
+
+// CMake variables of extra flags. They are passed as `<var>_INIT`, which only seeds `<var>` on the first
+// configure. Toolchain files (e.g. the NDK's) append their defaults to `<var>_INIT`, which passing `<var>`
+// itself would replace.
+var (
+	cmakeCompilerFlagsVars = []string{"CMAKE_C_FLAGS", "CMAKE_CXX_FLAGS", "CMAKE_ASM_FLAGS"}
+	cmakeLinkerFlagsVars   = []string{"CMAKE_SHARED_LINKER_FLAGS", "CMAKE_MODULE_LINKER_FLAGS", "CMAKE_EXE_LINKER_FLAGS"}
+)
+
+// Returns `-D<var>_INIT=<flags>` args of extra flags.
+func (bp *Builder) getCmakeFlagsInitArgs() []string {
+	var args []string
+	if flags := bp.getCmakeExtraCompilerFlags(); len(flags) > 0 {
+		for _, name := range cmakeCompilerFlagsVars {
+			args = append(args, "-D"+name+"_INIT="+strings.Join(flags, " "))
+		}
+	}
+	if flags := bp.getCmakeExtraLinkerFlags(); len(flags) > 0 {
+		for _, name := range cmakeLinkerFlagsVars {
+			args = append(args, "-D"+name+"_INIT="+strings.Join(flags, " "))
+		}
+	}
+	return args
+}
+
+// `<var>_INIT` only applies to new caches, so the build dir is regenerated when the flags change.
+// So is `ANDROID_SUPPORT_FLEXIBLE_PAGE_SIZES`, which the NDK toolchain file turns into `_INIT` flags.
+func (bp *Builder) cmakeFlagsInitChanged(args []string) bool {
+	cache := bp.readCmakeCache()
+	if cache == nil {
+		return false
+	}
+	defines := parseCmakeDefines(args)
+	names := []string{"ANDROID_SUPPORT_FLEXIBLE_PAGE_SIZES"}
+	for _, name := range slices.Concat(cmakeCompilerFlagsVars, cmakeLinkerFlagsVars) {
+		names = append(names, name+"_INIT")
+	}
+	for _, name := range names {
+		if cache[name] != strings.TrimSpace(defines[name]) {
+			bp.Shell.Log(j9.LogLevelInfo, fmt.Sprintf("%s changed, regenerating %s", name, bp.buildDir))
+			return true
+		}
+	}
+	return false
+}
+
+// NDK r27+ toolchain files support 16 KB pages via `ANDROID_SUPPORT_FLEXIBLE_PAGE_SIZES`.
+// Older NDKs and other page sizes need `-Wl,-z,max-page-size`, see `getCmakeExtraLinkerFlags`.
+func (bp *Builder) useNDKFlexiblePageSizes() bool {
+	return bp.CLIArgs.GetAndroidMaxPageSize() == DefaultAndroidMaxPageSize && bp.OS.GetNDKMajorVersion() >= 27
+}
+
+// Returns values of `-D<name>[:<type>]=<value>` args (K: name).
+func parseCmakeDefines(args []string) map[string]string {
+	defines := make(map[string]string)
+	for _, arg := range args {
+		if name, value, ok := parseCmakeDefine(arg); ok {
+			defines[name] = value
+		}
+	}
+	return defines
+}
+
+func parseCmakeDefine(arg string) (string, string, bool) {
+	def, ok := strings.CutPrefix(arg, "-D")
+	if !ok {
+		return "", "", false
+	}
+	key, value, ok := strings.Cut(def, "=")
+	if !ok {
+		return "", "", false
+	}
+	name, _, _ := strings.Cut(key, ":")
+	return name, value, true
+}
+
+// A `-D<var>=` arg in `args` (e.g. from `ProjectInitOptions.Args`) overrides `<var>_INIT`,
+// so the `<var>_INIT` flags are appended to it.
+func mergeCmakeFlagsArgs(args []string) []string {
+	defines := parseCmakeDefines(args)
+	res := make([]string, len(args))
+	for i, arg := range args {
+		res[i] = arg
+		if name, _, ok := parseCmakeDefine(arg); ok && !strings.HasSuffix(name, "_INIT") {
+			if flags := defines[name+"_INIT"]; flags != "" {
+				res[i] += " " + flags
+			}
+		}
+	}
+	return res
+}
+
+// Compiler flags not covered by CMake toolchain variables, see `cmakeCompilerFlagsVars`.
+func (bp *Builder) getCmakeExtraCompilerFlags() []string {
+	var flags []string
+	cliArgs := bp.CLIArgs
+	if bp.shouldSplitDebugSymbols() {
+		flags = append(flags, "-g")
+	}
+	if cliArgs.Reproducible {
+		flags = append(flags, bp.getReproducibleCompilerFlags()...)
+	}
+	flags = append(flags, bp.getProfileCompilerFlags()...)
+	return flags
+}
+
+// Linker flags not covered by CMake toolchain variables, see `cmakeLinkerFlagsVars`.
+func (bp *Builder) getCmakeExtraLinkerFlags() []string {
+	var flags []string
+	if bp.OS.IsAndroidPlatform() && !bp.useNDKFlexiblePageSizes() {
+		flags = append(flags, bp.getAndroidLDFlags()...)
+	}
+	flags = append(flags, bp.getProfileLinkerFlags()...)
+	return flags
+}
+
 func (bp *Builder) GoToBuildDir() string {
 	buildDir := bp.mustGetBuildDir(false)
 	bp.Shell.CD(buildDir)
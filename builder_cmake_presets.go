@@ -0,0 +1,124 @@
+package ku
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mgenware/j9/v3"
+)
+
+const CmakeUserPresetsFileName = "CMakeUserPresets.json"
+
+// Presets with this prefix are owned by ku and replaced on each run, others are kept.
+const CmakePresetPrefix = "ku-"
+
+// `toolchainFile` requires version 3 (CMake 3.21).
+const cmakePresetsMinVersion = 3
+
+// Returns the name of the presets of the current env, e.g. `ku-android-arm64-release`
+// or `ku-release-size-iphoneos-arm64-release` with a build profile.
+func (bp *Builder) GetCmakePresetName() string {
+	name := CmakePresetPrefix
+	if bp.CLIArgs.Profile != "" {
+		name += bp.CLIArgs.Profile + "-"
+	}
+	return name + bp.OS.GetSDKArchString() + "-" + strings.ToLower(bp.getCmakeBuildType())
+}
+
+// Writes a configure and build preset with the cache variables, generator, toolchain file, binary dir and env
+// of `cmake` args into CMakeUserPresets.json of the source dir. Presets of other envs and users are kept.
+func (bp *Builder) writeCmakeUserPresets(args []string, env []string) {
+	name := bp.GetCmakePresetName()
+	configurePreset := map[string]any{
+		"name":        name,
+		"displayName": "ku " + strings.TrimPrefix(name, CmakePresetPrefix),
+	}
+	cacheVars := map[string]any{}
+	sourceDir := "."
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		var value string
+		if i+1 < len(args) {
+			value = args[i+1]
+		}
+		switch {
+		case arg == "-S":
+			sourceDir = value
+			i++
+		case arg == "-B":
+			configurePreset["binaryDir"] = value
+			i++
+		case arg == "-G":
+			configurePreset["generator"] = value
+			i++
+		case arg == "--preset":
+			configurePreset["inherits"] = value
+			i++
+		case strings.HasPrefix(arg, "-D"):
+			key, val, _ := strings.Cut(arg[2:], "=")
+			if key, typ, ok := strings.Cut(key, ":"); ok {
+				cacheVars[key] = map[string]any{"type": typ, "value": val}
+			} else {
+				cacheVars[key] = val
+			}
+		}
+	}
+	if toolchain, ok := cacheVars["CMAKE_TOOLCHAIN_FILE"].(string); ok {
+		configurePreset["toolchainFile"] = toolchain
+		delete(cacheVars, "CMAKE_TOOLCHAIN_FILE")
+	}
+	configurePreset["cacheVariables"] = cacheVars
+	envMap := map[string]any{}
+	for _, pair := range env {
+		if key, val, ok := strings.Cut(pair, "="); ok {
+			envMap[key] = val
+		}
+	}
+	configurePreset["environment"] = envMap
+
+	buildPreset := map[string]any{
+		"name":            name,
+		"configurePreset": name,
+		"configuration":   bp.getCmakeBuildType(),
+	}
+
+	if !filepath.IsAbs(sourceDir) {
+		sourceDir = filepath.Join(bp.Shell.Tunnel.Dir(), sourceDir)
+	}
+	path := filepath.Join(sourceDir, CmakeUserPresetsFileName)
+	presets := map[string]any{}
+	if data, err := os.ReadFile(path); err == nil {
+		if err := json.Unmarshal(data, &presets); err != nil {
+			bp.Shell.Quit(fmt.Sprintf("Error parsing %s: %v", path, err))
+		}
+	}
+	if version, _ := presets["version"].(float64); version < cmakePresetsMinVersion {
+		presets["version"] = cmakePresetsMinVersion
+	}
+	presets["configurePresets"] = upsertCmakePreset(presets["configurePresets"], configurePreset)
+	presets["buildPresets"] = upsertCmakePreset(presets["buildPresets"], buildPreset)
+
+	data, err := json.MarshalIndent(presets, "", "  ")
+	if err != nil {
+		bp.Shell.Quit(fmt.Sprintf("Error encoding %s: %v", path, err))
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		bp.Shell.Quit(fmt.Sprintf("Error writing %s: %v", path, err))
+	}
+	bp.Shell.Log(j9.LogLevelInfo, fmt.Sprintf("Updated CMake preset %s in %s", name, path))
+}
+
+// Replaces the preset with the same name in `list`, or appends it.
+func upsertCmakePreset(list any, preset map[string]any) []any {
+	items, _ := list.([]any)
+	for i, item := range items {
+		if m, ok := item.(map[string]any); ok && m["name"] == preset["name"] {
+			items[i] = preset
+			return items
+		}
+	}
+	return append(items, preset)
+}
@@ -0,0 +1,110 @@
+package ku
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func newTestBuilder(cliArgs *CLIArgs, sdk SDKEnum, libType LibType) *Builder {
+	shell := NewShell(CreateDefaultTunnel(), cliArgs)
+	return &Builder{Shell: shell, OS: NewOSEnv(shell, sdk, ArchArm64), CLIArgs: cliArgs, LibType: libType}
+}
+
+func TestMergeCmakeFlagsArgs(t *testing.T) {
+	args := []string{
+		"-DCMAKE_C_FLAGS_INIT=-g -ffile-prefix-map=/a=.",
+		"-DCMAKE_CXX_FLAGS_INIT=-g",
+		"-DCMAKE_C_FLAGS=-DFOO",
+		"-DCMAKE_CXX_FLAGS:STRING=-DBAR",
+		"-DCMAKE_ASM_FLAGS=-DBAZ",
+		"-DFOO_INIT=x",
+		"--fresh",
+	}
+	want := []string{
+		"-DCMAKE_C_FLAGS_INIT=-g -ffile-prefix-map=/a=.",
+		"-DCMAKE_CXX_FLAGS_INIT=-g",
+		"-DCMAKE_C_FLAGS=-DFOO -g -ffile-prefix-map=/a=.",
+		"-DCMAKE_CXX_FLAGS:STRING=-DBAR -g",
+		"-DCMAKE_ASM_FLAGS=-DBAZ",
+		"-DFOO_INIT=x",
+		"--fresh",
+	}
+	if got := mergeCmakeFlagsArgs(args); !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestCmakeFlagsInitChanged(t *testing.T) {
+	dir := t.TempDir()
+	bp := newTestBuilder(&CLIArgs{SplitDebug: true}, SDKMacos, LibTypeDynamic)
+	bp.buildDir = dir
+	args := bp.getCmakeFlagsInitArgs()
+	want := []string{"-DCMAKE_C_FLAGS_INIT=-g", "-DCMAKE_CXX_FLAGS_INIT=-g", "-DCMAKE_ASM_FLAGS_INIT=-g"}
+	if !slices.Equal(args, want) {
+		t.Fatalf("got %q, want %q", args, want)
+	}
+	if bp.cmakeFlagsInitChanged(args) {
+		t.Error("changed without a cache")
+	}
+
+	cache := "# This is the CMakeCache file.\n" +
+		"//No help, variable specified on the command line.\n" +
+		"CMAKE_C_FLAGS_INIT:UNINITIALIZED=-g\n" +
+		"CMAKE_CXX_FLAGS_INIT:UNINITIALIZED=-g\n" +
+		"CMAKE_ASM_FLAGS_INIT:UNINITIALIZED=-g\n" +
+		"CMAKE_GENERATOR:INTERNAL=Ninja\n"
+	if err := os.WriteFile(filepath.Join(dir, "CMakeCache.txt"), []byte(cache), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := bp.GetCmakeBuildDirGenerator(); got != "Ninja" {
+		t.Errorf("generator: got %q", got)
+	}
+	if bp.cmakeFlagsInitChanged(args) {
+		t.Error("changed with the same flags")
+	}
+	if !bp.cmakeFlagsInitChanged(nil) {
+		t.Error("not changed after removing flags")
+	}
+}
+
+func TestCmakeSplitDebugFlags(t *testing.T) {
+	bp := newTestBuilder(&CLIArgs{SplitDebug: true}, SDKMacos, LibTypeDynamic)
+	args := append(bp.getCmakeFlagsInitArgs(), "-DCMAKE_C_FLAGS=-DFOO")
+	if got := mergeCmakeFlagsArgs(args)[3]; got != "-DCMAKE_C_FLAGS=-DFOO -g" {
+		t.Errorf("user flags: got %q", got)
+	}
+
+	// Static libs are not split, and `-DCMAKE_C_FLAGS` is left as is.
+	bp.LibType = LibTypeStatic
+	args = append(bp.getCmakeFlagsInitArgs(), "-DCMAKE_C_FLAGS=-DFOO")
+	if !slices.Equal(mergeCmakeFlagsArgs(args), []string{"-DCMAKE_C_FLAGS=-DFOO"}) {
+		t.Errorf("static: got %q", args)
+	}
+}
+
+func TestCmakeAndroidPageSizeFlags(t *testing.T) {
+	ndk := t.TempDir()
+	writeTestFiles(t, ndk, map[string]string{"source.properties": "Pkg.Revision = 26.3.11579264\n"})
+	t.Setenv("ANDROID_NDK_PATH", ndk)
+
+	// NDKs older than r27 don't support `ANDROID_SUPPORT_FLEXIBLE_PAGE_SIZES`.
+	bp := newTestBuilder(&CLIArgs{}, SDKAndroid, LibTypeDynamic)
+	if bp.useNDKFlexiblePageSizes() {
+		t.Error("r26 uses flexible page sizes")
+	}
+	want := []string{
+		"-DCMAKE_SHARED_LINKER_FLAGS_INIT=-Wl,-z,max-page-size=16384",
+		"-DCMAKE_MODULE_LINKER_FLAGS_INIT=-Wl,-z,max-page-size=16384",
+		"-DCMAKE_EXE_LINKER_FLAGS_INIT=-Wl,-z,max-page-size=16384",
+	}
+	if got := bp.getCmakeFlagsInitArgs(); !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	bp = newTestBuilder(&CLIArgs{Options: &CLIOptions{DisableAndroidMaxPageSize: true}}, SDKAndroid, LibTypeDynamic)
+	if got := bp.getCmakeFlagsInitArgs(); len(got) != 0 {
+		t.Errorf("disabled: got %q", got)
+	}
+}
@@ -1,6 +1,9 @@
 package ku
 
-import "strings"
+import (
+	"fmt"
+	"strings"
+)
 
 type GetCompilerFlagsOptions struct {
 	LD          bool
@@ -38,12 +41,31 @@ func (bp *Builder) GetCompilerFlagsList(opt *GetCompilerFlagsOptions) []string {
 		}
 	}
 
-	if cliArgs.DebugBuild {
+	if cliArgs.DebugBuild || bp.shouldSplitDebugSymbols() {
 		args = append(args, "-g")
 	}
 
 	args = append(args, "-fPIC")
 
+	if profile := cliArgs.GetBuildProfile(); profile != nil {
+		if profile.OptFlag != "" {
+			args = append(args, profile.OptFlag)
+		}
+		if opt.LD {
+			args = append(args, bp.getProfileLinkerFlags()...)
+		} else {
+			args = append(args, bp.getProfileCompilerFlags()...)
+		}
+	}
+
+	if cliArgs.Reproducible && !opt.LD {
+		args = append(args, bp.getReproducibleCompilerFlags()...)
+	}
+
+	if opt.LD && osEnv.IsAndroidPlatform() {
+		args = append(args, bp.getAndroidLDFlags()...)
+	}
+
 	if len(opt.ExtraFlags) > 0 {
 		args = append(args, opt.ExtraFlags...)
 	}
@@ -51,6 +73,14 @@ func (bp *Builder) GetCompilerFlagsList(opt *GetCompilerFlagsOptions) []string {
 	return args
 }
 
+func (bp *Builder) getAndroidLDFlags() []string {
+	var args []string
+	if pageSize := bp.CLIArgs.GetAndroidMaxPageSize(); pageSize > 0 {
+		args = append(args, fmt.Sprintf("-Wl,-z,max-page-size=%d", pageSize))
+	}
+	return args
+}
+
 func (bp *Builder) GetCompilerFlagsString(opt *GetCompilerFlagsOptions) string {
 	return strings.Join(bp.GetCompilerFlagsList(opt), " ")
 }
@@ -60,7 +90,7 @@ type GetToolchainEnvOptions struct {
 	// Useful for make projects using `./configure`.
 	// Note that might override existing compiler flags provided by source repo.
 	// In that case, it's recommended to use `--extra-xxxflags` during `./configure`.
-	// For CMake projects, these flags are passed via CMAKE_CXX_FLAGS, etc., so this option is not needed.
+	// For CMake projects, these flags are passed via CMAKE_CXX_FLAGS_INIT, etc., so this option is not needed.
 	// For Meson projects, these flags are passed via cross file, so this option is not needed.
 	MakeOnlySetCompilerFlags bool
 
@@ -156,11 +186,16 @@ func (bp *Builder) GetToolchainPathMapWithOptions(buildSys BuildSystemEnum) [][]
 // Returns environment variables for make project compiler configuration.
 // This includes CC, CXX, LD, and optionally CFLAGS, CXXFLAGS, LDFLAGS (when OverrideCompilerFlags is true).
 func (bp *Builder) GetMakeToolchainEnv(opt *GetToolchainEnvOptions) []string {
+	return append(bp.GetCoreSetupEnv(), bp.GetMakeToolchainVars(opt)...)
+}
+
+// Returns `GetMakeToolchainEnv` without core setup env, which can also be passed as make variables.
+func (bp *Builder) GetMakeToolchainVars(opt *GetToolchainEnvOptions) []string {
 	if opt == nil {
 		opt = &GetToolchainEnvOptions{}
 	}
 
-	env := bp.GetCoreSetupEnv()
+	env := []string{}
 	e := bp.OS
 
 	toolchainPathMap := bp.GetToolchainPathMap(BuildSystemMake)
@@ -187,5 +222,10 @@ func (bp *Builder) GetMakeToolchainEnv(opt *GetToolchainEnvOptions) []string {
 		}
 	}
 
+	if bp.CLIArgs.Reproducible && e.IsAndroidPlatform() {
+		// `AR_FLAGS` is used by libtool.
+		env = append(env, "ARFLAGS="+reproducibleArFlags, "AR_FLAGS="+reproducibleArFlags)
+	}
+
 	return env
 }
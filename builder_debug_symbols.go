@@ -0,0 +1,163 @@
+package ku
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mgenware/j9/v3"
+	"github.com/mgenware/ku-builder/io2"
+	"github.com/mgenware/ku-builder/objfile"
+)
+
+type SymbolsIndexEntry struct {
+	SDK    SDKEnum  `json:"sdk"`
+	Arch   ArchEnum `json:"arch"`
+	Target string   `json:"target"`
+	// Example: libavformat.61.dylib
+	File string `json:"file"`
+	// Relative to the symbols root dir.
+	// Example: iphoneos/arm64/libavformat.61.dylib.dSYM
+	Symbols string `json:"symbols"`
+}
+
+// K: build ID (ELF) or UUID (Mach-O).
+type SymbolsIndex map[string]*SymbolsIndexEntry
+
+// Static libs are built without debug info, only shared libs have symbols extracted.
+func (bp *Builder) shouldSplitDebugSymbols() bool {
+	cliArgs := bp.CLIArgs
+	return cliArgs.SplitDebug && !cliArgs.DebugBuild && bp.LibType != LibTypeStatic
+}
+
+// Stripping is done by `splitDebugSymbols` when debug symbols are split.
+func (bp *Builder) shouldStripOnInstall() bool {
+	return !bp.CLIArgs.DebugBuild && !bp.shouldSplitDebugSymbols()
+}
+
+// Extracts debug symbols of shared libs in OutLibDir to SymbolsDir, then strips them.
+// Libs without debug info (e.g. processed by a previous project) are skipped.
+func (bp *Builder) splitDebugSymbols() {
+	be := bp.BuildEnv
+	shell := bp.Shell
+	dylibExt := bp.OS.LibTypeExt(LibTypeDynamic)
+
+	entries, err := os.ReadDir(be.OutLibDir)
+	if err != nil {
+		shell.Quit(fmt.Sprintf("Error reading lib dir %s: %v", be.OutLibDir, err))
+	}
+	io2.Mkdirp(be.SymbolsDir)
+
+	indexPath := GetSymbolsIndexPath(be.BuildTypeDir)
+	index := readSymbolsIndex(shell, indexPath)
+	for _, entry := range entries {
+		name := entry.Name()
+		// Skip symbolic links (e.g. libz.so -> libz.so.1).
+		if entry.IsDir() || entry.Type()&fs.ModeSymlink != 0 {
+			continue
+		}
+		// Example: libz.dylib, libz.1.dylib, libz.so, libz.so.1.
+		if !strings.HasSuffix(name, dylibExt) && !strings.Contains(name, dylibExt+".") {
+			continue
+		}
+		file := filepath.Join(be.OutLibDir, name)
+		hasDebugInfo, err := objfile.HasDebugInfo(file)
+		if err != nil {
+			shell.Quit(fmt.Sprintf("Error reading debug info of %s: %v", file, err))
+		}
+		if !hasDebugInfo {
+			continue
+		}
+
+		id, err := objfile.ReadBuildID(file)
+		if err != nil {
+			shell.Quit(fmt.Sprintf("Error reading build ID of %s: %v", file, err))
+		}
+		symbolsFile := bp.extractDebugSymbols(file)
+		if id == "" {
+			shell.Log(j9.LogLevelWarning, "No build ID found in "+file+", skipping symbols index")
+			continue
+		}
+		rel, err := filepath.Rel(GetSymbolsRootDir(be.BuildTypeDir), symbolsFile)
+		if err != nil {
+			shell.Quit(fmt.Sprintf("Error resolving symbols path %s: %v", symbolsFile, err))
+		}
+		index[id] = &SymbolsIndexEntry{
+			SDK:     be.SDK,
+			Arch:    be.Arch,
+			Target:  be.Target,
+			File:    name,
+			Symbols: rel,
+		}
+	}
+	writeSymbolsIndex(shell, indexPath, index)
+}
+
+// Returns the path of the extracted symbols file.
+func (bp *Builder) extractDebugSymbols(file string) string {
+	be := bp.BuildEnv
+	e := bp.OS
+	shell := bp.Shell
+	name := filepath.Base(file)
+
+	if e.IsDarwinPlatform() {
+		dsymPath := filepath.Join(be.SymbolsDir, name+".dSYM")
+		if err := os.RemoveAll(dsymPath); err != nil {
+			shell.Quit(fmt.Sprintf("Error removing existing dSYM %s: %v", dsymPath, err))
+		}
+		shell.Spawn(&j9.SpawnOpt{
+			Name: e.RunXcodeFindCached("dsymutil"),
+			Args: []string{file, "-o", dsymPath},
+		})
+		e.StripFile(file, file)
+		shell.Log(j9.LogLevelSuccess, "✅ Extracted debug symbols to "+dsymPath)
+		return dsymPath
+	}
+
+	if e.IsAndroidPlatform() {
+		debugPath := filepath.Join(be.SymbolsDir, name+".debug")
+		objcopy := e.GetLLVMObjcopyPath()
+		shell.Spawn(&j9.SpawnOpt{
+			Name: objcopy,
+			Args: []string{"--only-keep-debug", file, debugPath},
+		})
+		e.StripFile(file, file)
+		shell.Spawn(&j9.SpawnOpt{
+			Name: objcopy,
+			Args: []string{"--add-gnu-debuglink=" + debugPath, file},
+		})
+		shell.Log(j9.LogLevelSuccess, "✅ Extracted debug symbols to "+debugPath)
+		return debugPath
+	}
+
+	e.ThrowUnsupportedError()
+	panic("unreachable")
+}
+
+func readSymbolsIndex(shell *Shell, path string) SymbolsIndex {
+	index := SymbolsIndex{}
+	if !io2.FileExists(path) {
+		return index
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		shell.Quit(fmt.Sprintf("Error reading symbols index %s: %v", path, err))
+	}
+	if err := json.Unmarshal(data, &index); err != nil {
+		shell.Quit(fmt.Sprintf("Error parsing symbols index %s: %v", path, err))
+	}
+	return index
+}
+
+func writeSymbolsIndex(shell *Shell, path string, index SymbolsIndex) {
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		shell.Quit(fmt.Sprintf("Error encoding symbols index: %v", err))
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		shell.Quit(fmt.Sprintf("Error writing symbols index %s: %v", path, err))
+	}
+}
@@ -0,0 +1,76 @@
+package ku
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Build tag added for the iOS simulator, which shares `GOOS=ios` with devices.
+const GoIosSimulatorTag = "iossimulator"
+
+// Returns GOOS, e.g. `ios` for both iOS devices and the simulator.
+func (e *OSEnv) GetGoOS() string {
+	switch e.SDK {
+	case SDKMacos:
+		return "darwin"
+	case SDKIos, SDKIosSimulator:
+		return "ios"
+	case SDKAndroid:
+		return "android"
+	}
+	e.ThrowUnsupportedError()
+	panic("unreachable")
+}
+
+func (e *OSEnv) GetGoArch() string {
+	switch e.Arch {
+	case ArchArm64:
+		return "arm64"
+	case ArchX86_64:
+		return "amd64"
+	}
+	e.ThrowUnsupportedError()
+	panic("unreachable")
+}
+
+// Returns `go build` env of cgo: GOOS, GOARCH, CC and CGO_*FLAGS from the NDK or Xcode toolchain.
+func (bp *Builder) GetGoEnv() []string {
+	e := bp.OS
+	cflags := bp.GetCompilerFlagsString(nil)
+	ldflags := bp.GetCompilerFlagsString(&GetCompilerFlagsOptions{LD: true})
+	return []string{
+		"GOOS=" + e.GetGoOS(),
+		"GOARCH=" + e.GetGoArch(),
+		"CGO_ENABLED=1",
+		"CC=" + e.GetCCPath(),
+		"CXX=" + e.GetCXXPath(),
+		"CGO_CFLAGS=" + cflags,
+		"CGO_CXXFLAGS=" + cflags,
+		"CGO_LDFLAGS=" + ldflags,
+	}
+}
+
+// Returns `go build` args of a `c-archive` or `c-shared` lib (depending on `LibType`) at `outFile`.
+func (bp *Builder) GetGoBuildArgs(outFile string, tags []string) []string {
+	buildMode := "c-archive"
+	if bp.LibType == LibTypeDynamic {
+		buildMode = "c-shared"
+	}
+	args := []string{"build", "-buildmode=" + buildMode, "-o", outFile}
+	if bp.OS.SDK == SDKIosSimulator {
+		tags = append([]string{GoIosSimulatorTag}, tags...)
+	}
+	if len(tags) > 0 {
+		args = append(args, "-tags="+strings.Join(tags, ","))
+	}
+	if bp.CLIArgs.Reproducible {
+		args = append(args, "-trimpath", "-buildvcs=false")
+	}
+	return args
+}
+
+// Returns the path of the lib built by `GoProject`, e.g. `<build dir>/libfoo.a`.
+// The generated header is at the same path with a `.h` extension.
+func (bp *Builder) GetGoLibPath() string {
+	return filepath.Join(bp.mustGetBuildDir(false), bp.BuildEnv.TargetLibName+bp.OS.LibTypeExt(bp.LibType))
+}
@@ -0,0 +1,11 @@
+package ku
+
+// Runs post-install steps and verifies the output file. Called by all `Install` implementations.
+func (bp *Builder) runPostInstall(outFile string, vfOpt *VerifyFileOptions) {
+	bp.bundleSanitizerRuntime()
+	if bp.shouldSplitDebugSymbols() {
+		bp.splitDebugSymbols()
+	}
+	bp.BuildEnv.VerifyFile(outFile, vfOpt)
+	bp.writeBuildManifest()
+}
@@ -0,0 +1,99 @@
+package ku
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/mgenware/j9/v3"
+	"github.com/mgenware/ku-builder/io2"
+)
+
+// Returns the dir of the isolated source of the current env, see `RepoInfo.IsolateSource`.
+// Example: <build>/sdk-android/arm64/ffmpeg/tmp/src/ffmpeg
+func (bp *Builder) GetIsolatedSourceDir() string {
+	return filepath.Join(bp.BuildEnv.TmpDir, "src", bp.Repo.Name)
+}
+
+// Materializes a pristine copy of `repoRootDir` for the current env and returns its path.
+// Git repos use a worktree. Repos with submodules or LFS (not shared by worktrees), local repos
+// (which may have uncommitted changes) and archives use a copy-on-write copy.
+// The copy is reused until the source revision changes or `-clean` is set.
+func (bp *Builder) isolateSource(repoRootDir string) string {
+	repo := bp.Repo
+	shell := bp.Shell
+	dir := bp.GetIsolatedSourceDir()
+	stampFile := dir + ".stamp"
+	isGit := io2.DirectoryExists(filepath.Join(repoRootDir, ".git"))
+	useWorktree := isGit && repo.LocalRepoDir == "" && !repo.needsSubmodules() && !repo.LFS
+
+	// Local repos are always copied again, as they can change without a new revision.
+	var stamp string
+	if isGit {
+		stamp = gitHead(shell, repoRootDir)
+	}
+	upToDate := repo.LocalRepoDir == "" && !bp.CLIArgs.CleanBuild && io2.DirectoryExists(dir)
+	if upToDate {
+		oldStamp, err := os.ReadFile(stampFile)
+		upToDate = err == nil && string(oldStamp) == stamp
+	}
+	if upToDate {
+		shell.CD(dir)
+		return dir
+	}
+
+	bp.removeIsolatedSource(repoRootDir, dir, isGit)
+	io2.Mkdirp(filepath.Dir(dir))
+	if useWorktree {
+		shell.Spawn(&j9.SpawnOpt{
+			Name: "git",
+			Args: []string{"-C", repoRootDir, "worktree", "add", "--detach", dir, "HEAD"},
+		})
+	} else {
+		copyDirCOW(shell, repoRootDir, dir)
+	}
+	if err := os.WriteFile(stampFile, []byte(stamp), 0644); err != nil {
+		shell.Quit(fmt.Sprintf("Error writing %s: %v", stampFile, err))
+	}
+	shell.Log(j9.LogLevelInfo, fmt.Sprintf("Isolated source of %s at %s", repo.Name, dir))
+
+	shell.CD(dir)
+	bp.runPostCheckoutCommands()
+	return dir
+}
+
+func (bp *Builder) removeIsolatedSource(repoRootDir string, dir string, isGit bool) {
+	if !io2.DirectoryExists(dir) {
+		return
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		bp.Shell.Quit(fmt.Sprintf("Error removing %s: %v", dir, err))
+	}
+	if isGit {
+		// Remove the worktree record of the removed dir, if any.
+		bp.Shell.Spawn(&j9.SpawnOpt{
+			Name: "git",
+			Args: []string{"-C", repoRootDir, "worktree", "prune"},
+		})
+	}
+}
+
+// Copies a dir with copy-on-write clones if supported by the file system (APFS, Btrfs, XFS).
+func copyDirCOW(shell *Shell, src string, dst string) {
+	var args []string
+	if runtime.GOOS == "darwin" {
+		// `-c` uses clonefile(2).
+		args = []string{"-c", "-R", src, dst}
+	} else {
+		args = []string{"-R", "--reflink=auto", src, dst}
+	}
+	if err := shell.SpawnRaw(&j9.SpawnOpt{Name: "cp", Args: args}); err == nil {
+		return
+	}
+	os.RemoveAll(dst)
+	shell.Spawn(&j9.SpawnOpt{
+		Name: "cp",
+		Args: []string{"-R", src, dst},
+	})
+}
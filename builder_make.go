@@ -33,9 +33,10 @@ func (bp *Builder) RunMakeWithArgs(opt *j9.SpawnOpt) {
 	env := append(bp.GetKuBuiltinEnv(false), opt.Env...)
 
 	bp.BuildEnv.Shell.Spawn(&j9.SpawnOpt{
-		Name: "make",
-		Args: []string{fmt.Sprintf("-j%v", numCores)},
-		Env:  env,
+		Name:       "make",
+		Args:       append([]string{fmt.Sprintf("-j%v", numCores)}, opt.Args...),
+		Env:        env,
+		WorkingDir: opt.WorkingDir,
 	})
 }
 
@@ -50,5 +51,5 @@ func (bp *Builder) RunMakeInstall(outFile string, vfOpt *VerifyFileOptions) {
 		Args: []string{"install"},
 		Env:  env,
 	})
-	bp.BuildEnv.VerifyFile(outFile, vfOpt)
+	bp.runPostInstall(outFile, vfOpt)
 }
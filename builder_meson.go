@@ -112,14 +112,12 @@ func (bp *Builder) RunMesonBuildOrInstall(opt *RunMesonBuildOrInstallOptions, ou
 	bp.NotNullOrQuit(opt, "opt")
 	bp.NotNullOrQuit(opt.Action, "opt.Action")
 
-	cliArgs := bp.CLIArgs
-
 	args := []string{
 		string(opt.Action),
 	}
 
 	// Strip during production install.
-	if opt.Action == MesonActionInstall && !cliArgs.DebugBuild {
+	if opt.Action == MesonActionInstall && bp.shouldStripOnInstall() {
 		args = append(args, "--strip")
 	}
 
@@ -152,7 +150,11 @@ func (bp *Builder) RunMesonBuildOrInstall(opt *RunMesonBuildOrInstallOptions, ou
 		Env:  env,
 	})
 
-	bp.BuildEnv.VerifyFile(outFile, vfOpt)
+	if opt.Action == MesonActionInstall {
+		bp.runPostInstall(outFile, vfOpt)
+	} else {
+		bp.BuildEnv.VerifyFile(outFile, vfOpt)
+	}
 }
 
 func (bp *Builder) RunMesonCompile() {
@@ -0,0 +1,55 @@
+package ku
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/mgenware/j9/v3"
+)
+
+// Optimization flag of release builds without a build profile `OptFlag`.
+const PlainMakeReleaseOptFlag = "-O2"
+
+// Returns make variables of the toolchain, compiler flags and install prefix for Makefiles without configure.
+// `extraVars` come last to allow overriding.
+// Command-line `CFLAGS` replace the Makefile's own flags (e.g. `-O2`), so release builds add `PlainMakeReleaseOptFlag`.
+func (bp *Builder) GetPlainMakeVars(useDestDir bool, extraVars []string) []string {
+	var optFlags []string
+	if profile := bp.CLIArgs.GetBuildProfile(); !bp.CLIArgs.DebugBuild && (profile == nil || profile.OptFlag == "") {
+		optFlags = []string{PlainMakeReleaseOptFlag}
+	}
+	vars := bp.GetMakeToolchainVars(&GetToolchainEnvOptions{
+		MakeOnlySetCompilerFlags:  true,
+		MakeOnlyExtraCAndCXXFlags: optFlags,
+	})
+	if useDestDir {
+		vars = append(vars, "DESTDIR="+bp.BuildEnv.OutDir, "PREFIX=")
+	} else {
+		vars = append(vars, "PREFIX="+bp.BuildEnv.OutDir)
+	}
+	return append(vars, extraVars...)
+}
+
+// Copies files and dirs matching `globs` (relative to `baseDir`) to `dstDir`. Quits if a glob matches nothing.
+func (bp *Builder) InstallByGlobs(baseDir string, globs []string, dstDir string) {
+	if len(globs) == 0 {
+		return
+	}
+	var files []string
+	for _, glob := range globs {
+		matches, err := filepath.Glob(filepath.Join(baseDir, glob))
+		if err != nil {
+			bp.Shell.Quit(fmt.Sprintf("Invalid glob %s: %v", glob, err))
+		}
+		if len(matches) == 0 {
+			bp.Shell.Quit(fmt.Sprintf("No files match %s in %s", glob, baseDir))
+		}
+		files = append(files, matches...)
+	}
+	// `-R` keeps symlinks (e.g. libfoo.so -> libfoo.so.1).
+	args := append([]string{"-R"}, files...)
+	bp.Shell.Spawn(&j9.SpawnOpt{
+		Name: "cp",
+		Args: append(args, dstDir),
+	})
+}
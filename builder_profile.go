@@ -0,0 +1,149 @@
+package ku
+
+import (
+	"sort"
+	"strings"
+)
+
+// Compiler flag presets selected by `-profile`. Outputs of a profile go to `build/profiles/<profile>`,
+// so variants don't overwrite each other.
+type BuildProfile struct {
+	// Optimization flag, e.g. `-Oz`. Overrides the optimization level of the build type.
+	OptFlag string
+	// Compiler flags.
+	CFlags []string
+	// Linker flags.
+	LDFlags []string
+	// Extra linker flags on Darwin platforms.
+	DarwinLDFlags []string
+	// Extra linker flags on Android.
+	AndroidLDFlags []string
+	// Clang sanitizer runtime name (e.g. `asan`, `ubsan_standalone`, `hwasan`). If set, the shared runtime
+	// is bundled with outputs and the build manifest is marked so outputs are not deployed by accident.
+	Sanitizer string
+	// Whether objects contain LLVM bitcode. Static libs must be created by LTO-aware `ar`/`ranlib`
+	// (Xcode's on Darwin, NDK's llvm-ar/llvm-ranlib on Android).
+	LTO bool
+}
+
+var BuiltinBuildProfiles = map[string]*BuildProfile{
+	"release-size": {
+		OptFlag:        "-Oz",
+		CFlags:         []string{"-ffunction-sections", "-fdata-sections"},
+		DarwinLDFlags:  []string{"-Wl,-dead_strip"},
+		AndroidLDFlags: []string{"-Wl,--gc-sections"},
+	},
+	"release-lto": {
+		CFlags:  []string{"-flto=thin"},
+		LDFlags: []string{"-flto=thin"},
+		LTO:     true,
+	},
+	"asan": {
+		OptFlag: "-O1",
+		CFlags:  []string{"-fsanitize=address", "-fno-omit-frame-pointer"},
+		// Link the shared runtime, which is bundled with outputs.
+		LDFlags:   []string{"-fsanitize=address", "-shared-libsan"},
+		Sanitizer: "asan",
+	},
+	"ubsan": {
+		CFlags:    []string{"-fsanitize=undefined", "-fno-sanitize-recover=undefined"},
+		LDFlags:   []string{"-fsanitize=undefined", "-shared-libsan"},
+		Sanitizer: "ubsan_standalone",
+	},
+	// Android arm64 only.
+	"hwasan": {
+		OptFlag:   "-O1",
+		CFlags:    []string{"-fsanitize=hwaddress", "-fno-omit-frame-pointer"},
+		LDFlags:   []string{"-fsanitize=hwaddress", "-shared-libsan"},
+		Sanitizer: "hwasan",
+	},
+	"coverage": {
+		OptFlag: "-O0",
+		CFlags:  []string{"-fprofile-instr-generate", "-fcoverage-mapping"},
+		LDFlags: []string{"-fprofile-instr-generate"},
+	},
+}
+
+// Returns the profile by name. Profiles in `CLIOptions.BuildProfiles` take precedence over builtin ones.
+func LookupBuildProfile(opt *CLIOptions, name string) *BuildProfile {
+	if opt != nil && opt.BuildProfiles[name] != nil {
+		return opt.BuildProfiles[name]
+	}
+	return BuiltinBuildProfiles[name]
+}
+
+func buildProfileNames(opt *CLIOptions) []string {
+	names := []string{}
+	for name := range BuiltinBuildProfiles {
+		names = append(names, name)
+	}
+	if opt != nil {
+		for name := range opt.BuildProfiles {
+			if BuiltinBuildProfiles[name] == nil {
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Returns nil if no profile is selected.
+func (a *CLIArgs) GetBuildProfile() *BuildProfile {
+	if a.Profile == "" {
+		return nil
+	}
+	return LookupBuildProfile(a.Options, a.Profile)
+}
+
+func (bp *Builder) getProfileCompilerFlags() []string {
+	p := bp.CLIArgs.GetBuildProfile()
+	if p == nil {
+		return nil
+	}
+	return p.CFlags
+}
+
+func (bp *Builder) getProfileLinkerFlags() []string {
+	p := bp.CLIArgs.GetBuildProfile()
+	if p == nil {
+		return nil
+	}
+	flags := append([]string{}, p.LDFlags...)
+	if bp.OS.IsDarwinPlatform() {
+		flags = append(flags, p.DarwinLDFlags...)
+	} else if bp.OS.IsAndroidPlatform() {
+		flags = append(flags, p.AndroidLDFlags...)
+	}
+	return flags
+}
+
+// CMake appends CMAKE_<LANG>_FLAGS_<CONFIG> after CMAKE_<LANG>_FLAGS, so the optimization flag
+// must replace the build type flags to take effect.
+func (bp *Builder) getCmakeProfileArgs(buildType string) []string {
+	p := bp.CLIArgs.GetBuildProfile()
+	if p == nil {
+		return nil
+	}
+	var args []string
+	if p.OptFlag != "" {
+		buildTypeFlags := []string{p.OptFlag}
+		if bp.CLIArgs.DebugBuild {
+			buildTypeFlags = append(buildTypeFlags, "-g")
+		} else {
+			buildTypeFlags = append(buildTypeFlags, "-DNDEBUG")
+		}
+		for _, lang := range []string{"C", "CXX", "OBJC", "OBJCXX"} {
+			args = append(args, "-DCMAKE_"+lang+"_FLAGS_"+strings.ToUpper(buildType)+"="+strings.Join(buildTypeFlags, " "))
+		}
+	}
+	// The NDK toolchain file already uses llvm-ar/llvm-ranlib. On Darwin, make sure CMake doesn't pick
+	// a non-LTO-aware `ar` (e.g. GNU binutils) from $PATH.
+	if p.LTO && bp.OS.IsDarwinPlatform() {
+		args = append(args,
+			"-DCMAKE_AR="+bp.OS.RunXcodeFindCached("ar"),
+			"-DCMAKE_RANLIB="+bp.OS.RunXcodeFindCached("ranlib"),
+		)
+	}
+	return args
+}
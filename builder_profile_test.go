@@ -0,0 +1,46 @@
+package ku
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestAsanProfileFlags(t *testing.T) {
+	cliArgs := &CLIArgs{Profile: "asan", Options: &CLIOptions{DisableAndroidMaxPageSize: true}}
+	bp := newTestBuilder(cliArgs, SDKAndroid, LibTypeDynamic)
+
+	want := []string{
+		"-DCMAKE_C_FLAGS_RELEASE=-O1 -DNDEBUG",
+		"-DCMAKE_CXX_FLAGS_RELEASE=-O1 -DNDEBUG",
+		"-DCMAKE_OBJC_FLAGS_RELEASE=-O1 -DNDEBUG",
+		"-DCMAKE_OBJCXX_FLAGS_RELEASE=-O1 -DNDEBUG",
+	}
+	if got := bp.getCmakeProfileArgs("Release"); !slices.Equal(got, want) {
+		t.Errorf("cmake: got %q, want %q", got, want)
+	}
+
+	want = []string{"-fPIC", "-O1", "-fsanitize=address", "-fno-omit-frame-pointer"}
+	if got := bp.GetCompilerFlagsList(nil); !slices.Equal(got, want) {
+		t.Errorf("cflags: got %q, want %q", got, want)
+	}
+	want = []string{"-fPIC", "-O1", "-fsanitize=address", "-shared-libsan"}
+	if got := bp.GetCompilerFlagsList(&GetCompilerFlagsOptions{LD: true}); !slices.Equal(got, want) {
+		t.Errorf("ldflags: got %q, want %q", got, want)
+	}
+
+	// Sanitizer flags are kept when `CMAKE_<LANG>_FLAGS` are set by the project.
+	args := append(bp.getCmakeFlagsInitArgs(), "-DCMAKE_C_FLAGS=-DFOO", "-DCMAKE_SHARED_LINKER_FLAGS=-lfoo")
+	want = []string{
+		"-DCMAKE_C_FLAGS_INIT=-fsanitize=address -fno-omit-frame-pointer",
+		"-DCMAKE_CXX_FLAGS_INIT=-fsanitize=address -fno-omit-frame-pointer",
+		"-DCMAKE_ASM_FLAGS_INIT=-fsanitize=address -fno-omit-frame-pointer",
+		"-DCMAKE_SHARED_LINKER_FLAGS_INIT=-fsanitize=address -shared-libsan",
+		"-DCMAKE_MODULE_LINKER_FLAGS_INIT=-fsanitize=address -shared-libsan",
+		"-DCMAKE_EXE_LINKER_FLAGS_INIT=-fsanitize=address -shared-libsan",
+		"-DCMAKE_C_FLAGS=-DFOO -fsanitize=address -fno-omit-frame-pointer",
+		"-DCMAKE_SHARED_LINKER_FLAGS=-lfoo -fsanitize=address -shared-libsan",
+	}
+	if got := mergeCmakeFlagsArgs(args); !slices.Equal(got, want) {
+		t.Errorf("merged: got %q, want %q", got, want)
+	}
+}
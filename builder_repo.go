@@ -31,6 +31,17 @@ type RepoInfo struct {
 	// If set, run these commands after checking out the repo.
 	PostCheckoutCommands [][]string
 
+	// If set, init and update submodules recursively (shallow) after clone, pull and commit checkout.
+	Submodules bool
+	// If set, only these submodules (paths relative to the repo root) are updated. Implies `Submodules`.
+	SubmodulePaths []string
+	// If set, fetch Git LFS objects after clone, pull and commit checkout. Requires git-lfs.
+	LFS bool
+
+	// If set, each SDK/arch builds in its own pristine copy of the source (see `GetIsolatedSourceDir`),
+	// so in-tree builds don't contaminate the shared checkout. `PostCheckoutCommands` run in the copy.
+	IsolateSource bool
+
 	// If set, go to this subdirectory after setting up the repo. The path is relative to the repo root.
 	// Some repos have the source code in a subdirectory instead of the repo root.
 	SourceSubDir []string
@@ -41,6 +52,9 @@ var repoPulled = make(map[string]bool)
 // Clones the repo if needed and goes to the repo directory. Returns the repo source directory.
 func (bp *Builder) CloneAndGotoRepoSource() string {
 	repoRootDir := bp.cloneAndGotoRepoRoot()
+	if bp.Repo.IsolateSource {
+		repoRootDir = bp.isolateSource(repoRootDir)
+	}
 
 	srcDir := repoRootDir
 	hasSubDir := len(bp.Repo.SourceSubDir) > 0
@@ -59,19 +73,31 @@ func (bp *Builder) cloneAndGotoRepoRoot() string {
 	repo := bp.Repo
 	shell := bp.Shell
 	repoDir := bp.repoRootDir
+	bp.recordMirrorSource(repo.MirrorSource())
 
 	if io2.DirectoryExists(repoDir) && !checkDirEmpty(shell, repoDir) {
 		shell.CD(repoDir)
 
-		// Call git pull if needed.
-		if repo.LocalRepoDir == "" && repo.UrlArchiveName == "" && repo.Commit == "" && !bp.CLIArgs.NoPull {
-			if !repoPulled[repoDir] {
-				shell.Spawn(&j9.SpawnOpt{
-					Name: "git",
-					Args: []string{"pull"},
-				})
-				repoPulled[repoDir] = true
-			}
+		if repo.LocalRepoDir != "" || repo.UrlArchiveName != "" {
+			return repoDir
+		}
+		bp.updateRemoteUrl(repoDir)
+
+		// Make sure the checkout matches the requested ref instead of building stale sources.
+		checkedOut := false
+		if commit := bp.getPinnedCommit(); commit != "" {
+			checkedOut = bp.checkoutCommit(repoDir, commit)
+		} else if repo.Tag != "" {
+			checkedOut = bp.checkoutTag(repoDir, repo.Tag)
+		} else if !bp.CLIArgs.NoPull && !bp.CLIArgs.Offline && !repoPulled[repoDir] {
+			// Call git pull if needed.
+			bp.checkoutLatest(repoDir)
+			repoPulled[repoDir] = true
+		}
+		bp.lockRepoHead(repoDir)
+		bp.updateRepoExtras(repoDir, false)
+		if checkedOut && !repo.IsolateSource {
+			bp.runPostCheckoutCommands()
 		}
 
 		return repoDir
@@ -79,6 +105,7 @@ func (bp *Builder) cloneAndGotoRepoRoot() string {
 
 	io2.Mkdirp(repoDir)
 	shell.CD(repoDir)
+	fetchUrl := bp.resolveRepoFetchUrl()
 
 	if repo.UrlArchiveName != "" {
 		// If `UrlArchiveName` is set, `repoDir` is now '<repo>/<UrlArchiveName>'. We need to go back to the parent directory to download and
@@ -93,8 +120,9 @@ func (bp *Builder) cloneAndGotoRepoRoot() string {
 
 		shell.Spawn(&j9.SpawnOpt{
 			Name: "curl",
-			Args: []string{"-L", "-o", tmpFile.Name(), repo.Url},
+			Args: []string{"-L", "-o", tmpFile.Name(), fetchUrl},
 		})
+		bp.lockArchive(tmpFile.Name())
 
 		var tarFlags string
 		if strings.HasSuffix(repo.Url, ".tar.gz") {
@@ -111,38 +139,35 @@ func (bp *Builder) cloneAndGotoRepoRoot() string {
 		return repoDir
 	}
 
-	var args []string
-	needCheckout := false
-	if repo.Tag != "" {
-		args = []string{"clone", "--branch", repo.Tag, "--depth", "1", repo.Url, repoDir}
-	} else if repo.Commit != "" {
-		args = []string{"clone", repo.Url, repoDir}
-		needCheckout = true
-	} else if repo.Branch != "" {
-		args = []string{"clone", "--branch", repo.Branch, "--depth", "1", repo.Url, repoDir}
+	if commit := bp.getPinnedCommit(); commit != "" {
+		// Fetch only the commit instead of cloning the full history.
+		shell.Spawn(&j9.SpawnOpt{
+			Name: "git",
+			Args: []string{"init", "-q", repoDir},
+		})
+		shell.Spawn(&j9.SpawnOpt{
+			Name: "git",
+			Args: []string{"-C", repoDir, "remote", "add", "origin", fetchUrl},
+		})
+		bp.checkoutCommit(repoDir, commit)
 	} else {
-		args = []string{"clone", "--depth", "1", repo.Url, repoDir}
-	}
-
-	shell.Spawn(&j9.SpawnOpt{
-		Name: "git",
-		Args: args,
-	})
-
-	if needCheckout {
+		var args []string
+		if repo.Tag != "" {
+			args = []string{"clone", "--branch", repo.Tag, "--depth", "1", fetchUrl, repoDir}
+		} else if repo.Branch != "" {
+			args = []string{"clone", "--branch", repo.Branch, "--depth", "1", fetchUrl, repoDir}
+		} else {
+			args = []string{"clone", "--depth", "1", fetchUrl, repoDir}
+		}
 		shell.Spawn(&j9.SpawnOpt{
 			Name: "git",
-			Args: []string{"-C", repoDir, "checkout", repo.Commit},
+			Args: args,
 		})
+		bp.lockRepoHead(repoDir)
 	}
-
-	if repo.PostCheckoutCommands != nil {
-		for _, cmd := range repo.PostCheckoutCommands {
-			shell.Spawn(&j9.SpawnOpt{
-				Name: cmd[0],
-				Args: cmd[1:],
-			})
-		}
+	bp.updateRepoExtras(repoDir, true)
+	if !repo.IsolateSource {
+		bp.runPostCheckoutCommands()
 	}
 
 	return repoDir
@@ -0,0 +1,60 @@
+package ku
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/mgenware/ku-builder/io2"
+)
+
+// Local paths are remapped to these fixed paths in reproducible builds.
+const ReproducibleRepoDir = "/ku/repo"
+const ReproducibleBuildDir = "/ku/build"
+
+// Deterministic ar flags for llvm-ar (Android only).
+// Apple ar doesn't support the `D` modifier and relies on `ZERO_AR_DATE` instead.
+const reproducibleArFlags = "crD"
+
+// Returns pairs of local paths and the fixed paths they are remapped to.
+func (bp *Builder) getReproduciblePathMap() [][]string {
+	pathMap := [][]string{
+		{GlobalRepoDir, ReproducibleRepoDir},
+		{globalBuildDir, ReproducibleBuildDir},
+	}
+	// Local repo dirs can live outside of `GlobalRepoDir`.
+	if bp.Repo != nil && bp.Repo.LocalRepoDir != "" {
+		pathMap = append(pathMap, []string{bp.Repo.LocalRepoDir, filepath.Join(ReproducibleRepoDir, bp.Repo.Name)})
+	}
+	return pathMap
+}
+
+// Returns compiler flags that remap local repo and build paths (embedded in debug info and `__FILE__`).
+func (bp *Builder) getReproducibleCompilerFlags() []string {
+	var args []string
+	for _, pair := range bp.getReproduciblePathMap() {
+		args = append(args,
+			"-ffile-prefix-map="+pair[0]+"="+pair[1],
+			"-fdebug-prefix-map="+pair[0]+"="+pair[1],
+		)
+	}
+	return args
+}
+
+func (bp *Builder) getReproducibleEnv() []string {
+	return []string{
+		"SOURCE_DATE_EPOCH=" + bp.getSourceDateEpoch(),
+		// Zeroes timestamps in archives and debug maps written by Apple ar/libtool/ld.
+		"ZERO_AR_DATE=1",
+	}
+}
+
+// Uses $SOURCE_DATE_EPOCH if set, otherwise the commit time of the repo, or 0 for non-git sources.
+func (bp *Builder) getSourceDateEpoch() string {
+	if epoch := os.Getenv("SOURCE_DATE_EPOCH"); epoch != "" {
+		return epoch
+	}
+	if !io2.DirectoryExists(filepath.Join(bp.repoRootDir, ".git")) {
+		return "0"
+	}
+	return bp.Shell.ShellCached("git -C \"" + bp.repoRootDir + "\" log -1 --format=%ct")
+}
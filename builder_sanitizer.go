@@ -0,0 +1,131 @@
+package ku
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mgenware/j9/v3"
+	"github.com/mgenware/ku-builder/io2"
+)
+
+// Prefix of clang runtime libs, e.g. libclang_rt.asan-aarch64-android.so.
+const SanitizerRuntimePrefix = "libclang_rt."
+
+// Returns the path of the shared sanitizer runtime of the current SDK/arch.
+// Android: <resource dir>/lib/linux/libclang_rt.<runtime>-<arch>-android.so
+// Darwin: <resource dir>/lib/darwin/libclang_rt.<runtime>_<osx|ios|iossim>_dynamic.dylib
+func (e *OSEnv) GetSanitizerRuntimePath(runtime string) string {
+	return e.cachedString("sanitizer-runtime-"+runtime, func() string {
+		resourceDir := e.shell.ShellCached(e.GetCCPath() + " -print-resource-dir")
+		var path string
+		switch e.SDK {
+		case SDKAndroid:
+			path = filepath.Join(resourceDir, "lib", "linux", SanitizerRuntimePrefix+runtime+"-"+GetOldArch(e.Arch)+"-android.so")
+		case SDKMacos:
+			path = filepath.Join(resourceDir, "lib", "darwin", SanitizerRuntimePrefix+runtime+"_osx_dynamic.dylib")
+		case SDKIos:
+			path = filepath.Join(resourceDir, "lib", "darwin", SanitizerRuntimePrefix+runtime+"_ios_dynamic.dylib")
+		case SDKIosSimulator:
+			path = filepath.Join(resourceDir, "lib", "darwin", SanitizerRuntimePrefix+runtime+"_iossim_dynamic.dylib")
+		default:
+			e.ThrowUnsupportedError()
+		}
+		if !io2.FileExists(path) {
+			e.shell.Quit(fmt.Sprintf("Sanitizer runtime %s is not available for %s: %s not found", runtime, e.GetSDKArchString(), path))
+		}
+		return path
+	})
+}
+
+func (bp *Builder) getSanitizer() string {
+	if profile := bp.CLIArgs.GetBuildProfile(); profile != nil {
+		return profile.Sanitizer
+	}
+	return ""
+}
+
+// Copies the sanitizer runtime to the lib dirs of the target so it's bundled with outputs.
+func (bp *Builder) bundleSanitizerRuntime() {
+	sanitizer := bp.getSanitizer()
+	if sanitizer == "" {
+		return
+	}
+	be := bp.BuildEnv
+	e := bp.OS
+	if sanitizer == "hwasan" && (!e.IsAndroidPlatform() || e.Arch != ArchArm64) {
+		bp.Shell.Quit(fmt.Sprintf("HWASan is only supported on Android arm64, got %s", e.GetSDKArchString()))
+	}
+
+	runtimePath := e.GetSanitizerRuntimePath(sanitizer)
+	dirs := []string{be.OutLibDir}
+	if be.DistLibDir != "" {
+		dirs = append(dirs, be.DistLibDir)
+	}
+	for _, dir := range dirs {
+		SyncToDir(runtimePath, dir, &SyncOptions{Shell: bp.Shell, Incremental: true})
+	}
+	bp.Shell.Log(j9.LogLevelSuccess, "✅ Bundled sanitizer runtime "+filepath.Base(runtimePath))
+}
+
+// Returns the `wrap.sh` script to load the sanitizer runtime in Android apps, or an empty string if not needed.
+// https://developer.android.com/ndk/guides/wrap-script
+func AndroidSanitizerWrapScript(sanitizer string) string {
+	switch sanitizer {
+	case "asan":
+		return `#!/system/bin/sh
+HERE="$(cd "$(dirname "$0")" && pwd)"
+export ASAN_OPTIONS=log_to_syslog=false,allow_user_segv_handler=1
+ASAN_LIB=$(ls "$HERE"/libclang_rt.asan-*-android.so)
+if [ -f "$HERE/libc++_shared.so" ]; then
+    # Workaround for https://github.com/android/ndk/issues/988.
+    export LD_PRELOAD="$ASAN_LIB $HERE/libc++_shared.so"
+else
+    export LD_PRELOAD="$ASAN_LIB"
+fi
+"$@"
+`
+	case "hwasan":
+		return `#!/system/bin/sh
+LD_HWASAN=1 exec "$@"
+`
+	}
+	return ""
+}
+
+// Copies the bundled sanitizer runtime from `srcLibDir` to `jniArchDir` and writes `wrap.sh` to
+// `<jniLibs>/../resources/lib/<abi>/wrap.sh`, where Android Gradle Plugin picks it up.
+func deployAndroidSanitizerRuntime(sanitizer string, srcLibDir string, dstLibsDir string, jniArchDir string, syncOpt *SyncOptions) {
+	shell := syncOpt.Shell
+	entries, err := os.ReadDir(srcLibDir)
+	if err != nil {
+		shell.Quit(fmt.Sprintf("Error reading lib dir %s: %v", srcLibDir, err))
+	}
+	found := false
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, SanitizerRuntimePrefix+sanitizer+"-") && strings.HasSuffix(name, "-android.so") {
+			SyncToDir(filepath.Join(srcLibDir, name), jniArchDir, syncOpt)
+			found = true
+		}
+	}
+	if !found {
+		shell.Quit(fmt.Sprintf("Sanitizer runtime %s not found in %s", sanitizer, srcLibDir))
+	}
+
+	script := AndroidSanitizerWrapScript(sanitizer)
+	if script == "" {
+		return
+	}
+	wrapPath := filepath.Join(filepath.Dir(dstLibsDir), "resources", "lib", filepath.Base(jniArchDir), "wrap.sh")
+	if syncOpt.DryRun {
+		shell.Log(j9.LogLevelInfo, "[dry-run] Write "+wrapPath)
+		return
+	}
+	io2.Mkdirp(filepath.Dir(wrapPath))
+	if err := os.WriteFile(wrapPath, []byte(script), 0755); err != nil {
+		shell.Quit(fmt.Sprintf("Error writing %s: %v", wrapPath, err))
+	}
+	shell.Log(j9.LogLevelInfo, "✅ Wrote "+wrapPath)
+}
@@ -0,0 +1,120 @@
+package ku
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/mgenware/j9/v3"
+	"github.com/mgenware/ku-builder/io2"
+)
+
+func (r *RepoInfo) needsSubmodules() bool {
+	return r.Submodules || len(r.SubmodulePaths) > 0
+}
+
+// Updates submodules and fetches LFS objects of the current checkout if enabled by `RepoInfo`.
+// Called after clone, pull and commit checkout. `cloned` is true for new clones.
+// Existing checkouts are updated without network access under `-offline` and `-no-pull`, new submodules
+// are cloned from the mirror under `-offline`.
+func (bp *Builder) updateRepoExtras(repoDir string, cloned bool) {
+	repo := bp.Repo
+	shell := bp.Shell
+	cliArgs := bp.CLIArgs
+	noFetch := !cloned && (cliArgs.Offline || cliArgs.NoPull)
+	if repo.needsSubmodules() {
+		args := append(bp.getSubmoduleMirrorArgs(), "-C", repoDir, "submodule", "update", "--init", "--recursive", "--depth", "1")
+		if noFetch {
+			args = append(args, "--no-fetch")
+		}
+		if len(repo.SubmodulePaths) > 0 {
+			args = append(args, "--")
+			args = append(args, repo.SubmodulePaths...)
+		}
+		shell.Spawn(&j9.SpawnOpt{Name: "git", Args: args})
+		bp.recordSubmoduleMirrorSources(repoDir)
+	}
+	if repo.LFS {
+		// `lfs checkout` only uses objects already fetched. LFS objects are not mirrored.
+		lfsCmd := "pull"
+		if noFetch || cliArgs.Offline {
+			lfsCmd = "checkout"
+		}
+		if cloned && cliArgs.Offline {
+			shell.Log(j9.LogLevelWarning, "LFS objects are not mirrored, LFS files of "+repo.Name+" stay as pointer files")
+		}
+		shell.Spawn(&j9.SpawnOpt{
+			Name: "git",
+			Args: []string{"-C", repoDir, "lfs", lfsCmd},
+		})
+		if repo.needsSubmodules() {
+			// Uninitialized submodules (not in `SubmodulePaths`) are skipped by `foreach`.
+			shell.Spawn(&j9.SpawnOpt{
+				Name: "git",
+				Args: []string{"-C", repoDir, "submodule", "foreach", "--recursive", "git lfs " + lfsCmd},
+			})
+		}
+	}
+}
+
+// Returns git config args redirecting mirrored git sources to the mirror dir under `-offline`.
+// Submodules are recorded in the mirror sources manifest when checked out online.
+func (bp *Builder) getSubmoduleMirrorArgs() []string {
+	if !bp.CLIArgs.Offline {
+		return nil
+	}
+	mirrorDir := GetMirrorDir()
+	if mirrorDir == "" {
+		bp.Shell.Quit(fmt.Sprintf("Offline mode requires a mirror dir, set %s.", MirrorDirEnv))
+	}
+	manifest, err := ReadMirrorSources(mirrorDir)
+	if err != nil {
+		bp.Shell.Quit(err.Error())
+	}
+	var args []string
+	for _, src := range manifest.Sources {
+		if src.Archive {
+			continue
+		}
+		args = append(args, "-c", "url.file://"+GetMirrorSourcePath(mirrorDir, src)+".insteadOf="+src.Url)
+	}
+	// Allows cloning submodules from `file://` URLs.
+	return append(args, "-c", "protocol.file.allow=always")
+}
+
+// Records URLs of initialized submodules (including nested ones), so `kuu mirror sync` fetches them.
+func (bp *Builder) recordSubmoduleMirrorSources(repoDir string) {
+	if GetMirrorDir() == "" || bp.CLIArgs.Offline {
+		return
+	}
+	output := bp.Shell.Shell("git -C \"" + repoDir + "\" submodule foreach --quiet --recursive \"git remote get-url origin\"")
+	for _, url := range strings.Fields(output) {
+		bp.recordMirrorSource(&MirrorSource{Url: url})
+	}
+}
+
+// Returns the HEAD commit of the repo and commits of its initialized submodules (K: path).
+// Returns an empty commit for non-git sources.
+func (bp *Builder) getRepoCommits() (string, map[string]string) {
+	repoDir := bp.repoRootDir
+	if !io2.DirectoryExists(filepath.Join(repoDir, ".git")) {
+		return "", nil
+	}
+	shell := bp.Shell
+	commit := shell.Shell("git -C \"" + repoDir + "\" rev-parse HEAD")
+	if !bp.Repo.needsSubmodules() {
+		return commit, nil
+	}
+
+	submodules := make(map[string]string)
+	// Example: ` 1a2b3c... third_party/zlib (v1.3.1)`. `-` prefix means not initialized.
+	output := shell.Shell("git -C \"" + repoDir + "\" submodule status --recursive")
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "-") {
+			continue
+		}
+		submodules[fields[1]] = strings.TrimLeft(fields[0], "+U")
+	}
+	return commit, submodules
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"slices"
+	"strings"
 
 	"github.com/mgenware/j9/v3"
 )
@@ -22,6 +23,18 @@ type CLIArgs struct {
 	HardenedRuntime bool
 	LibType         LibType
 	NoPull          bool
+	Reproducible    bool
+	SplitDebug      bool
+	// Build profile name, see `BuildProfile`.
+	Profile string
+	// Resolve all sources from the mirror dir, see `MirrorDirEnv`.
+	Offline bool
+	// Advance pins of built repos in `ku.lock` instead of honoring them.
+	UpdateLock bool
+	// CMake generator, see `GetCmakeGenArgsOptions.Generator`.
+	CmakeGenerator CmakeGeneratorEnum
+	// Write presets of the CMake configuration to CMakeUserPresets.json of the source dir.
+	CmakePresets bool
 
 	Options *CLIOptions
 }
@@ -54,6 +67,16 @@ type CLIOptions struct {
 	DefaultAction   CLIAction
 	CreateDistDir   bool
 
+	// Max page size passed to the Android linker via `-Wl,-z,max-page-size`.
+	// Defaults to `DefaultAndroidMaxPageSize` (16 KB, required by Google Play). For the default size,
+	// CMake builds with NDK r27+ use `ANDROID_SUPPORT_FLEXIBLE_PAGE_SIZES` instead.
+	AndroidMaxPageSize int
+	// Disables the Android max page size linker flag and the page alignment check of `.so` outputs.
+	DisableAndroidMaxPageSize bool
+
+	// Custom build profiles selectable by `-profile`. Overrides builtin profiles with the same name.
+	BuildProfiles map[string]*BuildProfile
+
 	BeforeParseFn func()
 	AfterParseFn  func(cliArgs *CLIArgs)
 }
@@ -97,6 +120,13 @@ func ParseCLIArgs(opt *CLIOptions) *CLIArgs {
 	signPtr := flag.String("sign", "", "Sign the output with the specified identity.")
 	hardenedRuntimePtr := flag.Bool("hardened", false, "Enable hardened runtime for macOS frameworks.")
 	noPullPtr := flag.Bool("no-pull", false, "Whether to skip git pull")
+	offlinePtr := flag.Bool("offline", false, "Fetch sources only from the mirror dir ($KU_MIRROR_DIR) and skip git pull.")
+	updateLockPtr := flag.Bool("update-lock", false, "Fetch the latest revisions of branch and latest repos and update their pins in ku.lock.")
+	splitDebugPtr := flag.Bool("split-debug", false, "Build release shared libs with debug info and extract symbols (dSYM / .debug) before stripping. Static libs are built without debug info.")
+	reproduciblePtr := flag.Bool("reproducible", false, "Remap local paths and use deterministic timestamps and archives for reproducible outputs.")
+	profilePtr := flag.String("profile", "", "Build profile. Builtin profiles: "+strings.Join(buildProfileNames(nil), ", ")+".")
+	cmakeGeneratorPtr := flag.String("cmake-generator", "", "CMake generator: \"Unix Makefiles\" (default), Ninja, \"Ninja Multi-Config\" or Xcode.")
+	cmakePresetsPtr := flag.Bool("cmake-presets", false, "Write configure and build presets of CMake projects to CMakeUserPresets.json of the source dir for IDEs.")
 	if opt.BeforeParseFn != nil {
 		opt.BeforeParseFn()
 	}
@@ -168,6 +198,16 @@ func ParseCLIArgs(opt *CLIOptions) *CLIArgs {
 		}
 	}
 
+	if *profilePtr != "" && LookupBuildProfile(opt, *profilePtr) == nil {
+		fmt.Printf("Unknown build profile: %v. Available profiles: %v\n", *profilePtr, buildProfileNames(opt))
+		os.Exit(1)
+	}
+
+	if *cmakeGeneratorPtr != "" && !SupportedCmakeGenerators[CmakeGeneratorEnum(*cmakeGeneratorPtr)] {
+		fmt.Printf("Unsupported CMake generator: %v\n", *cmakeGeneratorPtr)
+		os.Exit(1)
+	}
+
 	libType := LibTypeStatic
 	if *dylibPtr {
 		libType = LibTypeDynamic
@@ -186,6 +226,13 @@ func ParseCLIArgs(opt *CLIOptions) *CLIArgs {
 		LibType:         libType,
 		Options:         opt,
 		NoPull:          *noPullPtr,
+		Offline:         *offlinePtr,
+		UpdateLock:      *updateLockPtr,
+		CmakeGenerator:  CmakeGeneratorEnum(*cmakeGeneratorPtr),
+		CmakePresets:    *cmakePresetsPtr,
+		Reproducible:    *reproduciblePtr,
+		SplitDebug:      *splitDebugPtr,
+		Profile:         *profilePtr,
 		HardenedRuntime: *hardenedRuntimePtr,
 	}
 
@@ -196,6 +243,21 @@ func ParseCLIArgs(opt *CLIOptions) *CLIArgs {
 	return res
 }
 
+// Returns 0 if the Android max page size is disabled.
+func (a *CLIArgs) GetAndroidMaxPageSize() int {
+	opt := a.Options
+	if opt == nil {
+		return DefaultAndroidMaxPageSize
+	}
+	if opt.DisableAndroidMaxPageSize {
+		return 0
+	}
+	if opt.AndroidMaxPageSize > 0 {
+		return opt.AndroidMaxPageSize
+	}
+	return DefaultAndroidMaxPageSize
+}
+
 func CreateDefaultTunnel() *j9.Tunnel {
 	return j9.NewTunnel(j9.NewLocalNode(), j9.NewConsoleLogger())
 }
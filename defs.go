@@ -8,11 +8,16 @@ import (
 const MinMacosVersion = "11.0"
 const MinIosVersion = "14.0"
 const MinAndroidAPI = "26"
+const DefaultAndroidMaxPageSize = 16384
+
+// NDK prebuilt toolchain host. NDK only ships x86_64 binaries for macOS (universal since r23).
+const NDKHostTag = "darwin-x86_64"
 const OutDirName = "out"
 const DistDirName = "dist"
 
 var GlobalRepoDir string
 var globalBuildDir string
+var globalLockFile string
 
 type PlatformEnum string
 
@@ -92,9 +97,12 @@ func (t LibType) String() string {
 type BuildSystemEnum string
 
 const (
-	BuildSystemMake  BuildSystemEnum = "make"
-	BuildSystemCmake BuildSystemEnum = "cmake"
-	BuildSystemMeson BuildSystemEnum = "meson"
+	BuildSystemMake      BuildSystemEnum = "make"
+	BuildSystemCmake     BuildSystemEnum = "cmake"
+	BuildSystemMeson     BuildSystemEnum = "meson"
+	BuildSystemPlainMake BuildSystemEnum = "plain_make"
+	BuildSystemCargo     BuildSystemEnum = "cargo"
+	BuildSystemGo        BuildSystemEnum = "go"
 )
 
 var SupportedLibTypes = map[LibType]bool{
@@ -102,11 +110,25 @@ var SupportedLibTypes = map[LibType]bool{
 	LibTypeDynamic: true,
 }
 
+// Root dir of all build outputs.
+func GetBuildRootDir() string {
+	return globalBuildDir
+}
+
 func GetBuildTypeDir(debug bool) string {
+	return GetProfileBuildTypeDir(debug, "")
+}
+
+// Returns `build/<debug|release>`, or `build/profiles/<profile>/<debug|release>` if a profile is set.
+func GetProfileBuildTypeDir(debug bool, profile string) string {
+	dir := globalBuildDir
+	if profile != "" {
+		dir = filepath.Join(dir, "profiles", profile)
+	}
 	if debug {
-		return filepath.Join(globalBuildDir, "debug")
+		return filepath.Join(dir, "debug")
 	}
-	return filepath.Join(globalBuildDir, "release")
+	return filepath.Join(dir, "release")
 }
 
 func GetSDKDir(buildDir string, sdk SDKEnum) string {
@@ -125,6 +147,25 @@ func GetXCFrameworkDir(buildTypeDir string) string {
 	return filepath.Join(buildTypeDir, "xcframework")
 }
 
+// Separated debug symbols (dSYM / .debug) of release builds.
+func GetSymbolsRootDir(buildTypeDir string) string {
+	return filepath.Join(buildTypeDir, DistDirName, "symbols")
+}
+
+func GetSymbolsDir(buildTypeDir string, sdk SDKEnum, arch ArchEnum) string {
+	return filepath.Join(GetSymbolsRootDir(buildTypeDir), string(sdk), string(arch))
+}
+
+// Maps build IDs (ELF) / UUIDs (Mach-O) to symbol files.
+func GetSymbolsIndexPath(buildTypeDir string) string {
+	return filepath.Join(GetSymbolsRootDir(buildTypeDir), "index.json")
+}
+
+// Build manifest of a target, see `BuildManifest`.
+func GetBuildManifestPath(buildTypeDir string, target string) string {
+	return filepath.Join(buildTypeDir, "manifests", target+".json")
+}
+
 func GetOldArch(arch ArchEnum) string {
 	if arch == ArchArm64 {
 		return "aarch64"
@@ -157,4 +198,5 @@ func mustAbs(path string) string {
 func init() {
 	GlobalRepoDir = mustAbs("./repo")
 	globalBuildDir = mustAbs("./build")
+	globalLockFile = mustAbs("./" + LockFileName)
 }
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/mgenware/ku-builder"
+)
+
+func printUsage() {
+	fmt.Println("Usage: ku [-recipe <file>] build [build options]")
+	fmt.Println()
+	fmt.Println("Builds a target of a declarative recipe (default: " + ku.DefaultRecipeFile + "). Build options are the same as Go-based builds, e.g.:")
+	fmt.Println("  ku build -p android -ndk 28.2.13676358 -t libpng")
+	fmt.Println()
+	fmt.Println("Run `ku build -help` to list build options.")
+}
+
+func main() {
+	fs := flag.NewFlagSet("ku", flag.ExitOnError)
+	recipePtr := fs.String("recipe", ku.DefaultRecipeFile, "Recipe file.")
+	fs.Usage = printUsage
+	fs.Parse(os.Args[1:])
+	args := fs.Args()
+
+	if len(args) < 1 || args[0] != "build" {
+		printUsage()
+		os.Exit(1)
+	}
+
+	recipe, err := ku.LoadRecipe(*recipePtr)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	// Build options are parsed by `ku.ParseCLIArgs` from `os.Args`.
+	os.Args = append([]string{os.Args[0]}, args[1:]...)
+	ku.RunRecipe(recipe, nil)
+}
@@ -1,61 +1,229 @@
 package main
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
+	"sort"
+	"strings"
 
 	"github.com/mgenware/j9/v3"
 	"github.com/mgenware/ku-builder"
+	"github.com/mgenware/ku-builder/util"
 )
 
-func ReadKuConfig(shell *ku.Shell) map[string]interface{} {
-	// Read the file
-	data, err := os.ReadFile(".ku.json")
+const kuConfigFile = ".ku.json"
+
+// Root of `.ku.json`. Field tags are also used to generate `ku.schema.json`.
+type KuConfig struct {
+	Schema              string                         `json:"$schema,omitempty" desc:"JSON Schema of this file."`
+	DeployDefaultTarget string                         `json:"deploy_default_target,omitempty" desc:"Target used by kuu deploy when -target is not specified."`
+	DeployTargets       map[string]*DeployTargetConfig `json:"deploy_targets,omitempty" desc:"Deploy targets keyed by target name."`
+}
+
+type DeployTargetConfig struct {
+	SrcNames        []string `json:"src_names,omitempty" desc:"Lib names to deploy, e.g. libz. Supports ${VAR} interpolation."`
+	SrcNamesDarwin  []string `json:"src_names_darwin,omitempty" desc:"Lib names deployed to Darwin platforms. Overrides src_names."`
+	SrcNamesAndroid []string `json:"src_names_android,omitempty" desc:"Lib names deployed to Android. Overrides src_names."`
+	DestDirDarwin   string   `json:"dest_dir_darwin,omitempty" desc:"Destination dir of xcframeworks. Supports ~ and ${VAR} interpolation."`
+	DestDirAndroid  string   `json:"dest_dir_android,omitempty" desc:"Destination jniLibs dir. Supports ~ and ${VAR} interpolation."`
+	DestDirsDarwin  []string `json:"dest_dirs_darwin,omitempty" desc:"Additional destination dirs of xcframeworks, e.g. for multiple app repos."`
+	DestDirsAndroid []string `json:"dest_dirs_android,omitempty" desc:"Additional destination jniLibs dirs, e.g. for multiple app repos."`
+	Incremental     bool     `json:"incremental,omitempty" desc:"Only copy changed files (by SHA-256) and remove stale files. Same as -incremental."`
+	AllowSanitizer  bool     `json:"allow_sanitizer,omitempty" desc:"Allow deploying sanitizer builds (e.g. -profile asan) to this target. Same as -allow-sanitizer. Never set this for release destinations."`
+	PostDeploy      []string `json:"post_deploy,omitempty" desc:"Shell commands run in each destination dir after deploying. Not interpolated, use $KU_TARGET, $KU_PLATFORM and $KU_DEPLOY_DIR env vars instead. Example: touch ../../.gradle-sync."`
+}
+
+// Reads `.ku.json` in the current dir and checks its structure. Quits on errors.
+func ReadKuConfig(shell *ku.Shell) *KuConfig {
+	config, err := LoadKuConfig(kuConfigFile)
 	if err != nil {
-		shell.Quit(fmt.Sprintf("Error reading .ku.json: %v\n", err))
+		shell.Quit(err.Error())
 	}
+	shell.Log(j9.LogLevelInfo, "Read .ku.json successfully")
+	return config
+}
 
-	// Parse JSON into map
-	var result map[string]interface{}
-	err = json.Unmarshal(data, &result)
+// Reads and checks the structure of a config file. Variables are not resolved, see `ResolveAll`.
+func LoadKuConfig(file string) (*KuConfig, error) {
+	data, err := os.ReadFile(file)
 	if err != nil {
-		shell.Quit(fmt.Sprintf("Error parsing .ku.json: %v\n", err))
+		return nil, fmt.Errorf("error reading %s: %w", file, err)
+	}
+	var config KuConfig
+	if err := util.DecodeJSONStrict(file, data, &config); err != nil {
+		return nil, err
+	}
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
 	}
+	return &config, nil
+}
 
-	shell.Log(j9.LogLevelInfo, "Read .ku.json successfully")
-	return result
+func (c *KuConfig) Validate() error {
+	var problems []string
+	if c.DeployDefaultTarget != "" && c.DeployTargets[c.DeployDefaultTarget] == nil {
+		problems = append(problems, fmt.Sprintf("deploy_default_target %q is not in deploy_targets", c.DeployDefaultTarget))
+	}
+	for name, target := range c.DeployTargets {
+		if target == nil {
+			problems = append(problems, fmt.Sprintf("deploy_targets.%s must be an object", name))
+			continue
+		}
+		if len(target.SrcNames) == 0 && len(target.SrcNamesDarwin) == 0 && len(target.SrcNamesAndroid) == 0 {
+			problems = append(problems, fmt.Sprintf("deploy_targets.%s.src_names must not be empty", name))
+		}
+	}
+	return joinConfigProblems(problems)
 }
 
-func ReadConfigString(config map[string]interface{}, key string) string {
-	if value, ok := config[key]; ok {
-		if strValue, ok := value.(string); ok {
-			return strValue
+// Resolves all targets to catch undefined variables, used by `kuu config validate`.
+// Deploying only resolves the selected target.
+func (c *KuConfig) ResolveAll() error {
+	var problems []string
+	for name, target := range c.DeployTargets {
+		if target == nil {
+			continue
 		}
+		if _, err := target.Resolve(name); err != nil {
+			problems = append(problems, fmt.Sprintf("deploy_targets.%s: %v", name, err))
+		}
+	}
+	return joinConfigProblems(problems)
+}
+
+func joinConfigProblems(problems []string) error {
+	if len(problems) == 0 {
+		return nil
 	}
-	return ""
+	sort.Strings(problems)
+	return fmt.Errorf("%s", strings.Join(problems, "\n"))
 }
 
-func ReadConfigStringArray(config map[string]interface{}, key string) []string {
-	if value, ok := config[key]; ok {
-		if arrValue, ok := value.([]interface{}); ok {
-			strArr := make([]string, len(arrValue))
-			for i, v := range arrValue {
-				if strV, ok := v.(string); ok {
-					strArr[i] = strV
-				}
+// Returns a copy of the target config with `~` and `${VAR}` expanded.
+// `${KU_TARGET}` expands to the target name, other variables are read from the environment.
+func (t *DeployTargetConfig) Resolve(target string) (*DeployTargetConfig, error) {
+	res := &DeployTargetConfig{
+		Incremental:    t.Incremental,
+		AllowSanitizer: t.AllowSanitizer,
+		PostDeploy:     t.PostDeploy,
+	}
+	var err error
+	expand := func(s string) string {
+		if err != nil {
+			return ""
+		}
+		var v string
+		v, err = expandConfigString(s, target)
+		return v
+	}
+	expandList := func(list []string, dir bool) []string {
+		var values []string
+		for _, v := range list {
+			v = expand(v)
+			if dir {
+				v = resolveUserDir(v)
 			}
-			return strArr
+			values = append(values, v)
+		}
+		return values
+	}
+	res.SrcNames = expandList(t.SrcNames, false)
+	res.SrcNamesDarwin = expandList(t.SrcNamesDarwin, false)
+	res.SrcNamesAndroid = expandList(t.SrcNamesAndroid, false)
+	res.DestDirDarwin = resolveUserDir(expand(t.DestDirDarwin))
+	res.DestDirAndroid = resolveUserDir(expand(t.DestDirAndroid))
+	res.DestDirsDarwin = expandList(t.DestDirsDarwin, true)
+	res.DestDirsAndroid = expandList(t.DestDirsAndroid, true)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// Returns lib names to deploy to the platform.
+func (t *DeployTargetConfig) SrcNamesFor(platform ku.PlatformEnum) []string {
+	if platform == ku.PlatformAndroid {
+		if len(t.SrcNamesAndroid) > 0 {
+			return t.SrcNamesAndroid
+		}
+	} else if len(t.SrcNamesDarwin) > 0 {
+		return t.SrcNamesDarwin
+	}
+	return t.SrcNames
+}
+
+// Returns all destination dirs of the platform.
+func (t *DeployTargetConfig) DestDirsFor(platform ku.PlatformEnum) []string {
+	var dirs []string
+	if platform == ku.PlatformAndroid {
+		if t.DestDirAndroid != "" {
+			dirs = append(dirs, t.DestDirAndroid)
+		}
+		return append(dirs, t.DestDirsAndroid...)
+	}
+	if t.DestDirDarwin != "" {
+		dirs = append(dirs, t.DestDirDarwin)
+	}
+	return append(dirs, t.DestDirsDarwin...)
+}
+
+func expandConfigString(s, target string) (string, error) {
+	var missing []string
+	res := os.Expand(s, func(name string) string {
+		if name == "KU_TARGET" {
+			return target
+		}
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			missing = append(missing, name)
+		}
+		return value
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("undefined variable(s) in %q: %s", s, strings.Join(missing, ", "))
+	}
+	return res, nil
+}
+
+func resolveUserDir(dir string) string {
+	if strings.Contains(dir, "~") {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return dir
 		}
+		return strings.Replace(dir, "~", homeDir, 1)
 	}
-	return nil
+	return dir
 }
 
-func ReadConfigMap(config map[string]interface{}, key string) map[string]interface{} {
-	if value, ok := config[key]; ok {
-		if mapValue, ok := value.(map[string]interface{}); ok {
-			return mapValue
+// kuu config <validate|schema> [<file>]
+func RunKuConfig(shell *ku.Shell, subAction string, file string, output string) {
+	switch subAction {
+	case "validate":
+		if file == "" {
+			file = kuConfigFile
 		}
+		config, err := LoadKuConfig(file)
+		if err != nil {
+			shell.Quit(err.Error())
+		}
+		if err := config.ResolveAll(); err != nil {
+			shell.Quit(fmt.Sprintf("%s: %v", file, err))
+		}
+		shell.Log(j9.LogLevelSuccess, fmt.Sprintf("✅ %s is valid", file))
+
+	case "schema":
+		data := generateKuConfigSchema()
+		if output == "" {
+			fmt.Print(string(data))
+			return
+		}
+		if err := os.WriteFile(output, data, 0644); err != nil {
+			shell.Quit(fmt.Sprintf("Error writing schema: %v", err))
+		}
+		shell.Log(j9.LogLevelSuccess, fmt.Sprintf("✅ Schema written to %s", output))
+
+	default:
+		shell.Quit("Unknown config action. Supported actions: validate, schema.")
 	}
-	return nil
 }
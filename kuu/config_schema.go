@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"github.com/mgenware/ku-builder/util"
+)
+
+//go:generate go run . -o ../ku.schema.json config schema
+
+const kuConfigSchemaID = "https://raw.githubusercontent.com/mgenware/ku-builder/main/ku.schema.json"
+
+// Generates the JSON Schema of `.ku.json` from `KuConfig`.
+func generateKuConfigSchema() []byte {
+	schema := typeSchema(reflect.TypeOf(KuConfig{}))
+	schema["$schema"] = "http://json-schema.org/draft-07/schema#"
+	schema["$id"] = kuConfigSchemaID
+	schema["title"] = ".ku.json"
+	data, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		panic(err)
+	}
+	return append(data, '\n')
+}
+
+func typeSchema(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		props := map[string]interface{}{}
+		var required []string
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			name := util.JSONFieldName(f)
+			if name == "" {
+				continue
+			}
+			prop := typeSchema(f.Type)
+			if desc := f.Tag.Get("desc"); desc != "" {
+				prop["description"] = desc
+			}
+			props[name] = prop
+			if !strings.Contains(f.Tag.Get("json"), ",omitempty") {
+				required = append(required, name)
+			}
+		}
+		res := map[string]interface{}{
+			"type":                 "object",
+			"properties":           props,
+			"additionalProperties": false,
+		}
+		if len(required) > 0 {
+			res["required"] = required
+		}
+		return res
+	case reflect.Map:
+		return map[string]interface{}{
+			"type":                 "object",
+			"additionalProperties": typeSchema(t.Elem()),
+		}
+	case reflect.Slice:
+		return map[string]interface{}{
+			"type":  "array",
+			"items": typeSchema(t.Elem()),
+		}
+	case reflect.Int, reflect.Int64, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	default:
+		return map[string]interface{}{"type": util.JSONTypeName(t)}
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadKuConfigDoesNotResolveVars(t *testing.T) {
+	file := filepath.Join(t.TempDir(), kuConfigFile)
+	data := `{
+  "deploy_default_target": "app",
+  "deploy_targets": {
+    "app": {"src_names": ["libz"], "dest_dir_android": "/tmp/${KU_TARGET}"},
+    "other": {"src_names": ["libz"], "dest_dir_android": "${KU_TEST_UNDEFINED_DIR}/jniLibs"}
+  }
+}`
+	if err := os.WriteFile(file, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	config, err := LoadKuConfig(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	target, err := config.DeployTargets["app"].Resolve("app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if target.DestDirAndroid != "/tmp/app" {
+		t.Fatalf("unexpected dest dir %s", target.DestDirAndroid)
+	}
+	if err := config.ResolveAll(); err == nil || !strings.Contains(err.Error(), "deploy_targets.other") {
+		t.Fatalf("expected undefined variable error of deploy_targets.other, got %v", err)
+	}
+}
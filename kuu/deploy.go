@@ -2,15 +2,24 @@ package main
 
 import (
 	"fmt"
-	"os"
 	"path/filepath"
-	"strings"
 
 	"github.com/mgenware/j9/v3"
 	"github.com/mgenware/ku-builder"
 )
 
-func RunKuDeploy(shell *ku.Shell, target string, debug bool, platform ku.PlatformEnum) {
+type kuDeployOptions struct {
+	// Only copy changed files. Can also be enabled per target in config.
+	Incremental bool
+	// Only print what would be deployed.
+	DryRun bool
+	// Build profile of the outputs.
+	Profile string
+	// Allow deploying sanitizer builds. Can also be enabled per target in config.
+	AllowSanitizer bool
+}
+
+func RunKuDeploy(shell *ku.Shell, target string, debug bool, platform ku.PlatformEnum, opt *kuDeployOptions) {
 	rootKuConfig := ReadKuConfig(shell)
 
 	if platform == "" {
@@ -18,87 +27,130 @@ func RunKuDeploy(shell *ku.Shell, target string, debug bool, platform ku.Platfor
 	}
 	platformStr := string(platform)
 
-	defaultTarget := ReadConfigString(rootKuConfig, "deploy_default_target")
 	if target == "" {
-		if defaultTarget == "" {
+		if rootKuConfig.DeployDefaultTarget == "" {
 			shell.Quit("No target specified and no default target set in config.")
 		}
-		target = defaultTarget
+		target = rootKuConfig.DeployDefaultTarget
 	}
 
-	targetConfigMap := ReadConfigMap(rootKuConfig, "deploy_targets")
-	if len(targetConfigMap) <= 0 {
+	if len(rootKuConfig.DeployTargets) <= 0 {
 		shell.Quit("No target config map found in config.")
 	}
 
-	targetConfig := ReadConfigMap(targetConfigMap, target)
-	if len(targetConfig) <= 0 {
+	rawTargetConfig := rootKuConfig.DeployTargets[target]
+	if rawTargetConfig == nil {
 		shell.Quit(fmt.Sprintf("No config found for target: %s", target))
 	}
+	targetConfig, err := rawTargetConfig.Resolve(target)
+	if err != nil {
+		shell.Quit(err.Error())
+	}
 
-	srcNames := ReadConfigStringArray(targetConfig, "src_names")
-	darwinDestDir := resolveUserDir(ReadConfigString(targetConfig, "dest_dir_darwin"))
-	androidDestDir := resolveUserDir(ReadConfigString(targetConfig, "dest_dir_android"))
+	srcNames := targetConfig.SrcNamesFor(platform)
+	destDirs := targetConfig.DestDirsFor(platform)
+	incremental := opt.Incremental || targetConfig.Incremental
 
-	buildTypeDir := ku.GetBuildTypeDir(debug)
+	buildTypeDir := ku.GetProfileBuildTypeDir(debug, opt.Profile)
+	checkSanitizerDeploy(shell, buildTypeDir, target, opt.AllowSanitizer || targetConfig.AllowSanitizer)
 
 	if debug {
 		shell.Log(j9.LogLevelWarning, "☢️ You are deploying a debug build.")
 	}
+	if opt.DryRun {
+		shell.Log(j9.LogLevelWarning, "Dry run, no files will be changed.")
+	}
 
-	fmt.Printf("--- Target config: %s ---\n%v\n--- --- --- --- ---\n", target, targetConfig)
-
-	switch platform {
-	case ku.PlatformAndroid:
-		shell.Log(j9.LogLevelInfo, fmt.Sprintf("🚕 Deploying to Android: %s", androidDestDir))
-
-		if len(androidDestDir) <= 0 {
-			shell.Quit("No Android destination directory specified in config.")
-		}
+	fmt.Printf("--- Target config: %s ---\n%+v\n--- --- --- --- ---\n", target, *targetConfig)
 
-		ku.CopyJNILibsCore(&ku.CopyJNILibsOptions{
-			Shell:        shell,
-			DstLibsDir:   androidDestDir,
-			LibFileNames: srcNames,
-			Target:       target,
-			Debug:        debug,
-			KuDeploy:     true,
-		})
-
-	case ku.PlatformDarwin, ku.PlatformIos, ku.PlatformMacos:
-		shell.Log(j9.LogLevelVerbose, fmt.Sprintf("🚕 Deploying to %s: %s", platformStr, darwinDestDir))
+	if len(srcNames) == 0 {
+		shell.Quit(fmt.Sprintf("No %s source names specified in config.", platformStr))
+	}
+	if len(destDirs) == 0 {
+		shell.Quit(fmt.Sprintf("No %s destination directory specified in config.", platformStr))
+	}
 
-		if len(darwinDestDir) <= 0 {
-			shell.Quit(fmt.Sprintf("No %s destination directory specified in config.", platformStr))
+	for _, destDir := range destDirs {
+		switch platform {
+		case ku.PlatformAndroid:
+			shell.Log(j9.LogLevelInfo, fmt.Sprintf("🚕 Deploying to Android: %s", destDir))
+
+			ku.CopyJNILibsCore(&ku.CopyJNILibsOptions{
+				Shell:        shell,
+				DstLibsDir:   destDir,
+				LibFileNames: srcNames,
+				Target:       target,
+				Debug:        debug,
+				Profile:      opt.Profile,
+				KuDeploy:     true,
+				Incremental:  incremental,
+				DryRun:       opt.DryRun,
+			})
+
+		case ku.PlatformDarwin, ku.PlatformIos, ku.PlatformMacos:
+			shell.Log(j9.LogLevelVerbose, fmt.Sprintf("🚕 Deploying to %s: %s", platformStr, destDir))
+
+			xcRootDir := ku.GetXCFrameworkDir(buildTypeDir)
+			xcDir := filepath.Join(xcRootDir, platformStr, target)
+			deployDarwin(shell, xcDir, srcNames, destDir, &ku.SyncOptions{
+				Shell:       shell,
+				Incremental: incremental,
+				DryRun:      opt.DryRun,
+			})
+
+		default:
+			shell.Quit(fmt.Sprintf("Unsupported platform: %s", platform))
 		}
 
-		xcRootDir := ku.GetXCFrameworkDir(buildTypeDir)
-		xcDir := filepath.Join(xcRootDir, platformStr, target)
-		deployDarwin(shell, xcDir, srcNames, darwinDestDir)
-
-	default:
-		shell.Quit(fmt.Sprintf("Unsupported platform: %s", platform))
+		runPostDeploy(shell, targetConfig.PostDeploy, destDir, target, platform, opt.DryRun)
+	}
+}
 
+// Sanitizer builds are marked in the build manifest and are not deployed unless explicitly allowed,
+// so they don't end up in release destinations.
+func checkSanitizerDeploy(shell *ku.Shell, buildTypeDir string, target string, allow bool) {
+	manifest, err := ku.ReadBuildManifest(buildTypeDir, target)
+	if err != nil {
+		shell.Quit(err.Error())
+	}
+	if manifest == nil || manifest.Sanitizer == "" {
+		return
 	}
+	if !allow {
+		shell.Quit(fmt.Sprintf("Outputs of target %s are sanitizer (%s) builds. Pass -allow-sanitizer or set `allow_sanitizer` in the target config to deploy them.", target, manifest.Sanitizer))
+	}
+	shell.Log(j9.LogLevelWarning, fmt.Sprintf("☢️ You are deploying a sanitizer (%s) build.", manifest.Sanitizer))
 }
 
-func deployDarwin(shell *ku.Shell, xcDir string, srcNames []string, darwinDestDir string) {
+func deployDarwin(shell *ku.Shell, xcDir string, srcNames []string, darwinDestDir string, syncOpt *ku.SyncOptions) {
 	for _, srcName := range srcNames {
 		srcFileName := srcName + ".xcframework"
 		src := filepath.Join(xcDir, srcFileName)
 
-		ku.CPToDirByForce(shell, src, true, darwinDestDir)
-		shell.Log(j9.LogLevelInfo, fmt.Sprintf("✅ Deployed %s to %s", srcFileName, darwinDestDir))
+		ku.SyncToDir(src, darwinDestDir, syncOpt)
+		if !syncOpt.DryRun {
+			shell.Log(j9.LogLevelInfo, fmt.Sprintf("✅ Deployed %s to %s", srcFileName, darwinDestDir))
+		}
 	}
 }
 
-func resolveUserDir(dir string) string {
-	if strings.Contains(dir, "~") {
-		homeDir, err := os.UserHomeDir()
-		if err != nil {
-			return dir
+// Runs post-deploy shell commands in the destination dir.
+func runPostDeploy(shell *ku.Shell, commands []string, destDir string, target string, platform ku.PlatformEnum, dryRun bool) {
+	env := []string{
+		"KU_TARGET=" + target,
+		"KU_PLATFORM=" + string(platform),
+		"KU_DEPLOY_DIR=" + destDir,
+	}
+	for _, cmd := range commands {
+		if dryRun {
+			shell.Log(j9.LogLevelInfo, fmt.Sprintf("[dry-run] Run post-deploy command in %s: %s", destDir, cmd))
+			continue
 		}
-		return strings.Replace(dir, "~", homeDir, 1)
+		shell.Spawn(&j9.SpawnOpt{
+			Name:       "sh",
+			Args:       []string{"-c", cmd},
+			Env:        env,
+			WorkingDir: destDir,
+		})
 	}
-	return dir
 }
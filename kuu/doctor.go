@@ -0,0 +1,247 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/mgenware/ku-builder"
+)
+
+type doctorResult struct {
+	Name   string
+	OK     bool
+	Detail string
+	// Suggested fix when the check fails.
+	Fix string
+	// Optional checks only print a warning on failure.
+	Optional bool
+}
+
+type doctor struct {
+	results []*doctorResult
+}
+
+func (d *doctor) pass(name, detail string) {
+	d.results = append(d.results, &doctorResult{Name: name, OK: true, Detail: detail})
+}
+
+func (d *doctor) fail(name, detail, fix string) {
+	d.results = append(d.results, &doctorResult{Name: name, Detail: detail, Fix: fix})
+}
+
+func (d *doctor) warn(name, detail, fix string) {
+	d.results = append(d.results, &doctorResult{Name: name, Detail: detail, Fix: fix, Optional: true})
+}
+
+// Checks a tool in $PATH and prints its version.
+func (d *doctor) checkTool(name string, versionArgs []string, fix string, optional bool) {
+	path, err := exec.LookPath(name)
+	if err != nil {
+		if optional {
+			d.warn(name, "not found in $PATH", fix)
+		} else {
+			d.fail(name, "not found in $PATH", fix)
+		}
+		return
+	}
+	detail := path
+	if versionArgs != nil {
+		if ver := commandFirstLine(path, versionArgs...); ver != "" {
+			detail += " (" + ver + ")"
+		}
+	}
+	d.pass(name, detail)
+}
+
+func (d *doctor) checkFile(name, path, fix string) bool {
+	if _, err := os.Stat(path); err != nil {
+		d.fail(name, fmt.Sprintf("%s not found", path), fix)
+		return false
+	}
+	d.pass(name, path)
+	return true
+}
+
+// Checks if a dir (or its nearest existing parent when it's not created yet) is writable.
+func (d *doctor) checkWritableDir(name, dir string) {
+	existing := dir
+	for {
+		if _, err := os.Stat(existing); err == nil {
+			break
+		}
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			break
+		}
+		existing = parent
+	}
+	f, err := os.CreateTemp(existing, ".ku-doctor-*")
+	if err != nil {
+		d.fail(name, fmt.Sprintf("%s is not writable: %v", existing, err), "Fix the permissions of "+existing+" or run kuu in a writable project dir.")
+		return
+	}
+	f.Close()
+	os.Remove(f.Name())
+	d.pass(name, dir)
+}
+
+func commandFirstLine(name string, args ...string) string {
+	output, err := exec.Command(name, args...).CombinedOutput()
+	if err != nil {
+		return ""
+	}
+	line, _, _ := strings.Cut(strings.TrimSpace(string(output)), "\n")
+	return strings.TrimSpace(line)
+}
+
+func RunKuDoctor(shell *ku.Shell, platform ku.PlatformEnum, ndkVer string) {
+	if platform == "" {
+		shell.Quit("No platform specified. Please use -platform to specify it.")
+	}
+	sdks := ku.PlatformSDKs[platform]
+	if len(sdks) == 0 {
+		shell.Quit(fmt.Sprintf("Unsupported platform: %s", platform))
+	}
+
+	d := &doctor{}
+	if runtime.GOOS == "darwin" {
+		d.pass("host", runtime.GOOS+"/"+runtime.GOARCH)
+	} else {
+		d.fail("host", runtime.GOOS+"/"+runtime.GOARCH, "ku builds only run on macOS.")
+	}
+
+	d.checkTool("git", []string{"--version"}, "Install git (xcode-select --install or brew install git).", false)
+	d.checkTool("curl", []string{"--version"}, "Install curl (brew install curl).", false)
+	d.checkTool("tar", []string{"--version"}, "Install tar (brew install gnu-tar).", false)
+	d.checkTool("make", []string{"--version"}, "Install make (xcode-select --install).", false)
+	d.checkTool("cmake", []string{"--version"}, "Install CMake (brew install cmake).", false)
+	d.checkTool("meson", []string{"--version"}, "Install Meson (brew install meson).", false)
+	d.checkTool("ninja", []string{"--version"}, "Install Ninja, required by Meson (brew install ninja).", false)
+	d.checkTool("pkg-config", []string{"--version"}, "Install pkg-config (brew install pkg-config).", false)
+	d.checkTool("cargo", []string{"--version"}, "Install Rust, required by Cargo projects (https://rustup.rs).", true)
+	d.checkTool("cbindgen", []string{"--version"}, "Install cbindgen, required by Cargo projects generating headers (cargo install cbindgen).", true)
+
+	isAndroid := platform == ku.PlatformAndroid
+	if isAndroid {
+		doctorCheckAndroid(d, ndkVer)
+	} else {
+		doctorCheckDarwin(d, sdks)
+	}
+
+	d.checkWritableDir("repo dir", ku.GlobalRepoDir)
+	d.checkWritableDir("build dir", ku.GetBuildRootDir())
+	doctorCheckKuConfig(d)
+
+	failed := 0
+	for _, r := range d.results {
+		switch {
+		case r.OK:
+			fmt.Printf("✅ %s: %s\n", r.Name, r.Detail)
+		case r.Optional:
+			fmt.Printf("⚠️ %s: %s\n", r.Name, r.Detail)
+		default:
+			failed++
+			fmt.Printf("❌ %s: %s\n", r.Name, r.Detail)
+		}
+		if !r.OK && r.Fix != "" {
+			fmt.Printf("   Fix: %s\n", r.Fix)
+		}
+	}
+	fmt.Println()
+	if failed > 0 {
+		shell.Quit(fmt.Sprintf("%d check(s) failed.", failed))
+	}
+	fmt.Println("All checks passed.")
+}
+
+func doctorCheckDarwin(d *doctor, sdks []ku.SDKEnum) {
+	xcodeFix := "Install Xcode and run `sudo xcode-select -s /Applications/Xcode.app`."
+	d.checkTool("xcodebuild", []string{"-version"}, xcodeFix, false)
+	d.checkTool("xcrun", nil, xcodeFix, false)
+	for _, name := range []string{"lipo", "otool", "install_name_tool", "codesign", "libtool"} {
+		d.checkTool(name, nil, xcodeFix, false)
+	}
+	for _, name := range []string{"clang", "clang++", "dsymutil"} {
+		path := commandFirstLine("xcodebuild", "-find", name)
+		if path == "" {
+			d.fail("xcodebuild -find "+name, "not found", xcodeFix)
+		} else {
+			d.pass("xcodebuild -find "+name, path)
+		}
+	}
+	for _, sdk := range sdks {
+		name := "SDK " + string(sdk)
+		path := commandFirstLine("xcrun", "--sdk", string(sdk), "--show-sdk-path")
+		if path == "" {
+			d.fail(name, "xcrun cannot find the SDK", fmt.Sprintf("Install the %s SDK/platform in Xcode settings.", sdk))
+			continue
+		}
+		d.checkFile(name, path, fmt.Sprintf("Reinstall the %s SDK in Xcode settings.", sdk))
+	}
+	d.checkTool("llvm-objcopy", nil, "Install LLVM (brew install llvm) and add it to $PATH. Only required by symbol prefixing.", true)
+}
+
+func doctorCheckAndroid(d *doctor, ndkVer string) {
+	if os.Getenv("ANDROID_NDK_PATH") == "" {
+		if ndkVer == "" {
+			d.fail("NDK", "no NDK specified", "Use -ndk to specify an NDK version or path, or set $ANDROID_NDK_PATH.")
+			return
+		}
+		if !strings.HasPrefix(ndkVer, "/") {
+			sdkFix := "Install the Android SDK or set $ANDROID_SDK_PATH."
+			if !d.checkFile("Android SDK", ku.ResolveAndroidSDKPath(), sdkFix) {
+				return
+			}
+		}
+	}
+	ndkPath := ku.ResolveNDKPath(ndkVer)
+	ndkFix := "Install the NDK via Android Studio SDK Manager, or fix $ANDROID_NDK_PATH / -ndk."
+	if !d.checkFile("NDK", ndkPath, ndkFix) {
+		return
+	}
+	if rev := ku.ReadNDKRevision(ndkPath); rev != "" {
+		d.pass("NDK revision", rev)
+	} else {
+		d.warn("NDK revision", "cannot read Pkg.Revision from source.properties", ndkFix)
+	}
+	d.checkFile("NDK CMake toolchain file", filepath.Join(ndkPath, "build/cmake/android.toolchain.cmake"), ndkFix)
+
+	toolchainRoot := ku.GetNDKToolchainRootPath(ndkPath)
+	if !d.checkFile("NDK toolchain", toolchainRoot, "The NDK is missing the "+ku.NDKHostTag+" prebuilt toolchain. Reinstall the NDK.") {
+		return
+	}
+	d.checkFile("NDK sysroot", filepath.Join(toolchainRoot, "sysroot"), ndkFix)
+
+	binDir := filepath.Join(toolchainRoot, "bin")
+	apiFix := fmt.Sprintf("This NDK doesn't support API level %s (MinAndroidAPI). Use a newer NDK.", ku.MinAndroidAPI)
+	for _, arch := range ku.SDKArchs[ku.SDKAndroid] {
+		for _, cpp := range []bool{false, true} {
+			name := ku.GetNDKClangName(arch, cpp)
+			d.checkFile(name, filepath.Join(binDir, name), apiFix)
+		}
+	}
+	for _, name := range []string{"llvm-ar", "llvm-as", "llvm-nm", "llvm-ranlib", "llvm-strip", "llvm-objcopy", "llvm-readelf"} {
+		d.checkFile(name, filepath.Join(binDir, name), ndkFix)
+	}
+}
+
+func doctorCheckKuConfig(d *doctor) {
+	const name = ".ku.json"
+	if _, err := os.Stat(name); err != nil {
+		if os.IsNotExist(err) {
+			d.warn(name, "not found", "Create .ku.json in the project root to use kuu deploy.")
+		} else {
+			d.fail(name, err.Error(), "Fix the permissions of .ku.json.")
+		}
+		return
+	}
+	if _, err := LoadKuConfig(name); err != nil {
+		d.fail(name, err.Error(), "Fix .ku.json. Run `kuu config validate` to see all errors.")
+		return
+	}
+	d.pass(name, "valid")
+}
@@ -25,7 +25,7 @@ func ndkPath(shell *ku.Shell, ndkVer string) string {
 }
 
 func ndkBinPath(shell *ku.Shell, ndkVer string, name string) string {
-	return filepath.Join(ndkPath(shell, ndkVer), "toolchains", "llvm", "prebuilt", "darwin-x86_64", "bin", name)
+	return filepath.Join(ndkPath(shell, ndkVer), "toolchains", "llvm", "prebuilt", ku.NDKHostTag, "bin", name)
 }
 
 func mustHaveNDKVer(ndkVer string) string {
@@ -37,12 +37,20 @@ func mustHaveNDKVer(ndkVer string) string {
 }
 
 func printUsage() {
-	fmt.Println("Usage: kuu [options] <action> <input>")
+	fmt.Println("Usage: kuu [options] <action> <input> [<input2>]")
 	fmt.Println()
 	fmt.Println("Actions:")
 	fmt.Println("  dep       List dependencies of the input file")
 	fmt.Println("  symbol    List exported symbols of the input file")
 	fmt.Println("  deploy    Run deployment for the specified target and platform. Input is ignored.")
+	fmt.Println("  config    Run a .ku.json action. `config validate [<file>]` validates .ku.json (or the given file). `config schema` prints the JSON Schema of .ku.json (writes to -o if specified).")
+	fmt.Println("  doctor    Check the build environment (tools, SDKs, NDK, build dirs, .ku.json) for the specified platform. Exits with non-zero status on failure. Input is ignored.")
+	fmt.Println("  mirror    Manage the source mirror at $KU_MIRROR_DIR. `mirror sync [<recipe>]` fetches or updates recorded sources (and sources of the recipe). `mirror list` lists recorded sources.")
+	fmt.Println("  lock      Manage ku.lock. `lock update [<repo>]` pins the repo (or all pinned repos) to the latest revision of its branch.")
+	fmt.Println("  inspect   Print a binary report (format, archs, platform, min OS, install name/SONAME, rpaths, dependencies, exported symbols, code signature, sections) of a Mach-O, ELF, ar, xcframework or AAR input. Runs on any host.")
+	fmt.Println("  size      Print the size breakdown of the input by object file, section and symbol. With -baseline, print the growth against the baseline. Runs on any host.")
+	fmt.Println("  merge     Merge the input static libs (all remaining args) into a single archive specified by -o.")
+	fmt.Println("  repro-check  Compare two build outputs (files or dirs) byte-for-byte and report differing files and archive members.")
 	fmt.Println()
 	fmt.Println("Options:")
 	fmt.Println("  -platform  Platform. Supported platforms: macos(m), ios(i), android(a), darwin(d).")
@@ -50,6 +58,15 @@ func printUsage() {
 	fmt.Println("  -target    Build target.")
 	fmt.Println("  -t         -target shorthand.")
 	fmt.Println("  -ndk       NDK version.")
+	fmt.Println("  -o         Output file.")
+	fmt.Println("  -json      Output JSON.")
+	fmt.Println("  -top       Number of largest entries to print by size. 0 prints all.")
+	fmt.Println("  -baseline  Baseline binary or JSON manifest (size -json output) to diff against by size.")
+	fmt.Println("  -profile   Build profile of the outputs to deploy.")
+	fmt.Println("  -dry-run   Print what deploy would change without changing files.")
+	fmt.Println("  -incremental  Only copy changed files by deploy.")
+	fmt.Println("  -allow-sanitizer  Allow deploying sanitizer builds.")
+	fmt.Println("  -threshold Max allowed total growth against -baseline by size, in bytes (e.g. 10240) or percent (e.g. 5%).")
 	fmt.Println("  -debug     Debug build.")
 	fmt.Println("  -help      Show usage information.")
 }
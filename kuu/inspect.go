@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mgenware/ku-builder"
+	"github.com/mgenware/ku-builder/objfile"
+)
+
+func RunKuInspect(shell *ku.Shell, input string, jsonOutput bool) {
+	report, err := objfile.Inspect(input)
+	if err != nil {
+		shell.Quit(fmt.Sprintf("Error: %v", err))
+	}
+	if jsonOutput {
+		printJSON(shell, report)
+		return
+	}
+	printReport(report, 0)
+}
+
+func printReport(r *objfile.Report, depth int) {
+	indent := strings.Repeat("  ", depth)
+	field := func(name string, value interface{}) {
+		fmt.Printf("%s%-18s %v\n", indent, name+":", value)
+	}
+	list := func(name string, values []string) {
+		if len(values) == 0 {
+			return
+		}
+		fmt.Printf("%s%s\n", indent, name+":")
+		for _, v := range values {
+			fmt.Printf("%s  %s\n", indent, v)
+		}
+	}
+
+	fmt.Printf("%s%s\n", indent, r.Name)
+	field("Format", r.Format)
+	if r.Type != "" {
+		field("Type", r.Type)
+	}
+	if r.Arch != "" {
+		field("Arch", r.Arch)
+	}
+	if len(r.Archs) > 0 {
+		field("Archs", strings.Join(r.Archs, ", "))
+	}
+	if r.Platform != "" {
+		field("Platform", r.Platform)
+	}
+	if r.MinOS != "" {
+		field("Min OS", r.MinOS)
+	}
+	if r.SDKVersion != "" {
+		field("SDK", r.SDKVersion)
+	}
+	if r.InstallName != "" {
+		field("Install name", r.InstallName)
+	}
+	if r.SOName != "" {
+		field("SONAME", r.SOName)
+	}
+	if r.Members > 0 {
+		field("Members", r.Members)
+	}
+	// Containers only list their children.
+	if len(r.Children) == 0 {
+		field("Exported symbols", r.ExportedSymbols)
+		field("Code signature", r.CodeSigned)
+	}
+	list("Rpaths", r.Rpaths)
+	list("Dependencies", r.Dependencies)
+	if len(r.Sections) > 0 {
+		fmt.Printf("%s%s\n", indent, "Sections:")
+		for _, sec := range r.Sections {
+			fmt.Printf("%s  %-28s %d\n", indent, sec.Name, sec.Size)
+		}
+	}
+	for _, child := range r.Children {
+		fmt.Println()
+		printReport(child, depth+1)
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/mgenware/j9/v3"
+	"github.com/mgenware/ku-builder"
+)
+
+// kuu lock update [<repo>]
+func RunKuLock(shell *ku.Shell, subAction string, repoName string) {
+	lockPath := ku.GetLockFilePath()
+	lock, err := ku.ReadLockFile(lockPath)
+	if err != nil {
+		shell.Quit(err.Error())
+	}
+
+	switch subAction {
+	case "update":
+		var names []string
+		if repoName != "" {
+			if lock.Repos[repoName] == nil {
+				shell.Quit(fmt.Sprintf("Repo %s not found in %s", repoName, lockPath))
+			}
+			names = []string{repoName}
+		} else {
+			for name := range lock.Repos {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+		}
+		if len(names) == 0 {
+			shell.Quit(fmt.Sprintf("No repos pinned in %s", lockPath))
+		}
+
+		for _, name := range names {
+			entry := lock.Repos[name]
+			var pin string
+			if entry.SHA256 != "" {
+				entry.SHA256 = downloadSHA256(shell, entry.Url)
+				pin = "sha256:" + entry.SHA256
+			} else {
+				entry.Commit = lsRemoteCommit(shell, entry.Url, entry.Branch)
+				pin = entry.Commit
+			}
+			shell.Log(j9.LogLevelInfo, fmt.Sprintf("🔒 Pinned %s to %s", name, pin))
+		}
+		if err := lock.Write(lockPath); err != nil {
+			shell.Quit(fmt.Sprintf("Error writing %s: %v", lockPath, err))
+		}
+		shell.Log(j9.LogLevelSuccess, fmt.Sprintf("✅ Updated %d repo(s) in %s", len(names), lockPath))
+
+	default:
+		shell.Quit("Unknown lock action. Supported actions: update.")
+	}
+}
+
+// Returns the latest commit of the branch, or of the default branch if `branch` is empty.
+func lsRemoteCommit(shell *ku.Shell, url string, branch string) string {
+	ref := "HEAD"
+	if branch != "" {
+		ref = "refs/heads/" + branch
+	}
+	output := shell.Shell("git ls-remote \"" + url + "\" \"" + ref + "\"")
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[1] == ref {
+			return fields[0]
+		}
+	}
+	shell.Quit(fmt.Sprintf("Ref %s not found in %s", ref, url))
+	return ""
+}
+
+func downloadSHA256(shell *ku.Shell, url string) string {
+	tmpFile, err := os.CreateTemp("", "ku_download")
+	if err != nil {
+		shell.Quit(fmt.Sprintf("Error creating temp file: %v", err))
+	}
+	tmpFile.Close()
+	defer os.Remove(tmpFile.Name())
+
+	shell.Spawn(&j9.SpawnOpt{
+		Name: "curl",
+		Args: []string{"-fL", "-o", tmpFile.Name(), url},
+	})
+
+	hash, err := ku.FileHash(tmpFile.Name())
+	if err != nil {
+		shell.Quit(fmt.Sprintf("Error hashing %s: %v", url, err))
+	}
+	return hex.EncodeToString(hash)
+}
@@ -10,6 +10,16 @@ import (
 	"github.com/mgenware/ku-builder"
 )
 
+var hostIndependentActions = map[string]bool{
+	"config":      true,
+	"doctor":      true,
+	"inspect":     true,
+	"lock":        true,
+	"mirror":      true,
+	"repro-check": true,
+	"size":        true,
+}
+
 func main() {
 
 	var platformInput string
@@ -22,23 +32,26 @@ func main() {
 	flag.StringVar(&target, "t", "", "-target shorthand.")
 
 	ndkPtr := flag.String("ndk", "", "NDK version.")
+	outputPtr := flag.String("o", "", "Output file.")
 
 	var debug bool
 	flag.BoolVar(&debug, "debug", false, "Debug build.")
 	flag.BoolVar(&debug, "d", false, "-debug shorthand.")
 
+	jsonPtr := flag.Bool("json", false, "Output JSON.")
+	topPtr := flag.Int("top", 20, "Number of largest entries to print by size. 0 prints all.")
+	baselinePtr := flag.String("baseline", "", "Baseline binary or JSON manifest (size -json output) to diff against by size.")
+	thresholdPtr := flag.String("threshold", "", "Max allowed total growth against -baseline by size, in bytes (e.g. 10240) or percent (e.g. 5%).")
+	profilePtr := flag.String("profile", "", "Build profile of the outputs to deploy.")
+	dryRunPtr := flag.Bool("dry-run", false, "Print what deploy would change without changing files.")
+	allowSanitizerPtr := flag.Bool("allow-sanitizer", false, "Allow deploying sanitizer builds.")
+	incrementalPtr := flag.Bool("incremental", false, "Only copy changed files by deploy.")
 	verbosePtr := flag.Bool("verbose", false, "Verbose output.")
 	helpPtr := flag.Bool("help", false, "Show usage information.")
 
 	flag.Parse()
 	args := flag.Args()
 
-	// This program only runs on macOS.
-	if runtime.GOOS != "darwin" {
-		fmt.Println("This program only runs on macOS.")
-		return
-	}
-
 	if *helpPtr {
 		printUsage()
 		return
@@ -50,12 +63,22 @@ func main() {
 	}
 
 	ndkVer := *ndkPtr
-	resolvedPlatform = ku.ParsePlatformString(platformInput, false)
 	action := args[0]
+	// Actions implemented in pure Go can run on any host.
+	if runtime.GOOS != "darwin" && !hostIndependentActions[action] {
+		fmt.Println("This action only runs on macOS.")
+		return
+	}
+
+	resolvedPlatform = ku.ParsePlatformString(platformInput, false)
 	var input string
 	if len(args) > 1 {
 		input = args[1]
 	}
+	var input2 string
+	if len(args) > 2 {
+		input2 = args[2]
+	}
 	if ndkVer != "" && resolvedPlatform == "" {
 		resolvedPlatform = ku.PlatformAndroid
 	}
@@ -105,7 +128,47 @@ func main() {
 		}
 
 	case "deploy":
-		RunKuDeploy(shell, target, debug, resolvedPlatform)
+		RunKuDeploy(shell, target, debug, resolvedPlatform, &kuDeployOptions{
+			Incremental:    *incrementalPtr,
+			DryRun:         *dryRunPtr,
+			Profile:        *profilePtr,
+			AllowSanitizer: *allowSanitizerPtr,
+		})
+
+	case "config":
+		requireInput()
+		RunKuConfig(shell, input, input2, *outputPtr)
+
+	case "lock":
+		requireInput()
+		RunKuLock(shell, input, input2)
+
+	case "mirror":
+		requireInput()
+		RunKuMirror(shell, input, input2)
+
+	case "doctor":
+		RunKuDoctor(shell, resolvedPlatform, ndkVer)
+
+	case "merge":
+		RunKuMerge(shell, args[1:], *outputPtr, ndkVer)
+
+	case "inspect":
+		requireInput()
+		RunKuInspect(shell, input, *jsonPtr)
+
+	case "size":
+		requireInput()
+		RunKuSize(shell, input, &kuSizeOptions{
+			Top:       *topPtr,
+			Baseline:  *baselinePtr,
+			Threshold: *thresholdPtr,
+			JSON:      *jsonPtr,
+		})
+
+	case "repro-check":
+		requireInput()
+		RunReproCheck(shell, input, input2)
 
 	default:
 		shell.Quit("Unknown action")
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/mgenware/ku-builder"
+	"github.com/mgenware/ku-builder/objfile"
+)
+
+// Merges static libs into `output`. The platform is detected from the object files of all inputs.
+func RunKuMerge(shell *ku.Shell, inputs []string, output string, ndkVer string) {
+	if len(inputs) == 0 {
+		shell.Quit("No input provided.")
+	}
+	if output == "" {
+		shell.Quit("No output specified. Please use -o to specify it.")
+	}
+
+	darwin := detectMergePlatform(shell, inputs)
+	var tool string
+	if !darwin {
+		tool = ndkBinPath(shell, mustHaveNDKVer(ndkVer), "llvm-ar")
+	}
+	ku.MergeStaticLibs(&ku.MergeStaticLibsOptions{
+		Shell:  shell,
+		Inputs: inputs,
+		Output: output,
+		Darwin: darwin,
+		Tool:   tool,
+	})
+}
+
+// Returns true if all object files of `inputs` are Mach-O files, false if all are ELF files.
+// Quits on fat archives and on mixed Mach-O and ELF inputs.
+func detectMergePlatform(shell *ku.Shell, inputs []string) bool {
+	// K: format, V: first input containing it.
+	formats := make(map[objfile.Format]string)
+	for _, input := range inputs {
+		data, err := os.ReadFile(input)
+		if err != nil {
+			shell.Quit(err.Error())
+		}
+		switch objfile.DetectFormatFromBytes(data) {
+		case objfile.FormatArchive:
+		case objfile.FormatFat:
+			shell.Quit(fmt.Sprintf("%s is a fat archive. Merge each arch separately (`lipo -thin`), then combine them with `lipo -create`.", input))
+		default:
+			shell.Quit(fmt.Sprintf("%s is not a static lib.", input))
+		}
+		members, err := objfile.ParseArchive(data)
+		if err != nil {
+			shell.Quit(fmt.Sprintf("Error parsing %s: %v", input, err))
+		}
+		for _, m := range members {
+			format := objfile.DetectFormatFromBytes(m.Data)
+			if format != objfile.FormatMachO && format != objfile.FormatELF {
+				continue
+			}
+			if _, ok := formats[format]; !ok {
+				formats[format] = input
+			}
+		}
+	}
+
+	machoInput, hasMachO := formats[objfile.FormatMachO]
+	elfInput, hasELF := formats[objfile.FormatELF]
+	if hasMachO && hasELF {
+		shell.Quit(fmt.Sprintf("Cannot merge Mach-O (%s) and ELF (%s) libs.", machoInput, elfInput))
+	}
+	if !hasMachO && !hasELF {
+		shell.Quit("No Mach-O or ELF object files found in inputs.")
+	}
+	return hasMachO
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/mgenware/j9/v3"
+	"github.com/mgenware/ku-builder"
+)
+
+// kuu mirror <sync|list> [<recipe>]
+func RunKuMirror(shell *ku.Shell, subAction string, recipeFile string) {
+	mirrorDir := ku.GetMirrorDir()
+	if mirrorDir == "" {
+		shell.Quit(fmt.Sprintf("No mirror dir, set %s.", ku.MirrorDirEnv))
+	}
+
+	// Sources of the recipe are added to the sources manifest.
+	if recipeFile != "" {
+		recipe, err := ku.LoadRecipe(recipeFile)
+		if err != nil {
+			shell.Quit(err.Error())
+		}
+		if err := ku.AddMirrorSources(mirrorDir, recipe.MirrorSources()); err != nil {
+			shell.Quit(err.Error())
+		}
+	}
+	manifest, err := ku.ReadMirrorSources(mirrorDir)
+	if err != nil {
+		shell.Quit(err.Error())
+	}
+
+	switch subAction {
+	case "sync":
+		if len(manifest.Sources) == 0 {
+			shell.Quit(fmt.Sprintf("No sources in %s. Pass a recipe file or run builds with %s set to record sources.", ku.MirrorSourcesFile, ku.MirrorDirEnv))
+		}
+		ku.SyncMirror(shell, mirrorDir, manifest.Sources)
+		shell.Log(j9.LogLevelSuccess, fmt.Sprintf("✅ Synced %d source(s) to %s", len(manifest.Sources), mirrorDir))
+
+	case "list":
+		missing := make(map[ku.MirrorSource]bool)
+		for _, src := range ku.MissingMirrorSources(mirrorDir, manifest.Sources) {
+			missing[src] = true
+		}
+		for _, src := range manifest.Sources {
+			if missing[src] {
+				fmt.Printf("❌ %s\n", src)
+			} else {
+				fmt.Printf("✅ %s -> %s\n", src, ku.GetMirrorSourcePath(mirrorDir, src))
+			}
+		}
+
+	default:
+		shell.Quit("Unknown mirror action. Supported actions: sync, list.")
+	}
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/mgenware/j9/v3"
+	"github.com/mgenware/ku-builder"
+	"github.com/mgenware/ku-builder/io2"
+	"github.com/mgenware/ku-builder/objfile"
+)
+
+// Compares two build outputs (files or directories) byte-for-byte and reports differing files and archive members.
+func RunReproCheck(shell *ku.Shell, a, b string) {
+	if a == "" || b == "" {
+		shell.Quit("repro-check requires two inputs")
+	}
+
+	var diffs []string
+	if io2.DirectoryExists(a) && io2.DirectoryExists(b) {
+		aFiles := listRelFiles(shell, a)
+		bFiles := listRelFiles(shell, b)
+		for _, rel := range aFiles {
+			if !bFiles.has(rel) {
+				diffs = append(diffs, "only in "+a+": "+rel)
+				continue
+			}
+			diffs = append(diffs, compareFiles(shell, filepath.Join(a, rel), filepath.Join(b, rel), rel)...)
+		}
+		for _, rel := range bFiles {
+			if !aFiles.has(rel) {
+				diffs = append(diffs, "only in "+b+": "+rel)
+			}
+		}
+	} else {
+		diffs = compareFiles(shell, a, b, filepath.Base(a))
+	}
+
+	if len(diffs) > 0 {
+		for _, d := range diffs {
+			shell.Log(j9.LogLevelError, d)
+		}
+		shell.Quit(fmt.Sprintf("❌ Outputs are not reproducible, %d difference(s) found", len(diffs)))
+	}
+	shell.Log(j9.LogLevelSuccess, "✅ Outputs are identical")
+}
+
+type relFileList []string
+
+func (l relFileList) has(s string) bool {
+	i := sort.SearchStrings(l, s)
+	return i < len(l) && l[i] == s
+}
+
+func listRelFiles(shell *ku.Shell, root string) relFileList {
+	var files relFileList
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, rel)
+		return nil
+	})
+	if err != nil {
+		shell.Quit(fmt.Sprintf("Error listing files in %s: %v", root, err))
+	}
+	sort.Strings(files)
+	return files
+}
+
+func compareFiles(shell *ku.Shell, a, b, name string) []string {
+	aInfo, err := os.Lstat(a)
+	if err != nil {
+		shell.Quit(fmt.Sprintf("Error reading %s: %v", a, err))
+	}
+	bInfo, err := os.Lstat(b)
+	if err != nil {
+		shell.Quit(fmt.Sprintf("Error reading %s: %v", b, err))
+	}
+	// Compare symlink targets instead of following them.
+	if aInfo.Mode()&fs.ModeSymlink != 0 || bInfo.Mode()&fs.ModeSymlink != 0 {
+		aLink, _ := os.Readlink(a)
+		bLink, _ := os.Readlink(b)
+		if aLink != bLink {
+			return []string{fmt.Sprintf("%s: symlink differs (%s vs %s)", name, aLink, bLink)}
+		}
+		return nil
+	}
+
+	aData, err := os.ReadFile(a)
+	if err != nil {
+		shell.Quit(fmt.Sprintf("Error reading %s: %v", a, err))
+	}
+	bData, err := os.ReadFile(b)
+	if err != nil {
+		shell.Quit(fmt.Sprintf("Error reading %s: %v", b, err))
+	}
+	if bytes.Equal(aData, bData) {
+		return nil
+	}
+	if objfile.IsArchive(aData) && objfile.IsArchive(bData) {
+		return compareArchives(name, aData, bData)
+	}
+	return []string{fmt.Sprintf("%s: content differs (%d vs %d bytes)", name, len(aData), len(bData))}
+}
+
+func compareArchives(name string, aData, bData []byte) []string {
+	aMembers, aErr := objfile.ParseArchive(aData)
+	bMembers, bErr := objfile.ParseArchive(bData)
+	if aErr != nil || bErr != nil {
+		return []string{fmt.Sprintf("%s: content differs (archive parse error: %v, %v)", name, aErr, bErr)}
+	}
+
+	aKeys, aMap := archiveMemberMap(aMembers)
+	bKeys, bMap := archiveMemberMap(bMembers)
+	var diffs []string
+	for _, key := range aKeys {
+		bData, ok := bMap[key]
+		if !ok {
+			diffs = append(diffs, fmt.Sprintf("%s: member %s only in first archive", name, key))
+		} else if !bytes.Equal(aMap[key], bData) {
+			diffs = append(diffs, fmt.Sprintf("%s: member %s differs", name, key))
+		}
+	}
+	for _, key := range bKeys {
+		if _, ok := aMap[key]; !ok {
+			diffs = append(diffs, fmt.Sprintf("%s: member %s only in second archive", name, key))
+		}
+	}
+	if len(diffs) == 0 {
+		// All members are identical, so the difference is in member headers or member order.
+		diffs = append(diffs, fmt.Sprintf("%s: member headers or order differ (timestamps, uid/gid or modes)", name))
+	}
+	return diffs
+}
+
+// Archives can contain members with the same name, duplicates are keyed as `name#2`, `name#3`, etc.
+func archiveMemberMap(members []*objfile.ArMember) ([]string, map[string][]byte) {
+	var keys []string
+	m := make(map[string][]byte)
+	counts := make(map[string]int)
+	for _, member := range members {
+		counts[member.Name]++
+		key := member.Name
+		if n := counts[member.Name]; n > 1 {
+			key = fmt.Sprintf("%s#%d", member.Name, n)
+		}
+		keys = append(keys, key)
+		m[key] = member.Data
+	}
+	return keys, m
+}
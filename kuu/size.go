@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/mgenware/j9/v3"
+	"github.com/mgenware/ku-builder"
+	"github.com/mgenware/ku-builder/objfile"
+)
+
+type kuSizeOptions struct {
+	// Number of largest entries to print per category.
+	Top int
+	// Optional baseline binary or JSON manifest (output of `kuu size -json`).
+	Baseline string
+	// Max allowed total growth against the baseline, in bytes (e.g. 10240) or percent (e.g. 5%).
+	Threshold string
+	JSON      bool
+}
+
+func RunKuSize(shell *ku.Shell, input string, opt *kuSizeOptions) {
+	report, err := objfile.ComputeSize(input)
+	if err != nil {
+		shell.Quit(fmt.Sprintf("Error: %v", err))
+	}
+
+	if opt.Baseline == "" {
+		if opt.JSON {
+			printJSON(shell, report)
+			return
+		}
+		fmt.Printf("%s: %d bytes\n", report.Name, report.Total)
+		printSizeEntries("Objects", report.Objects, opt.Top)
+		printSizeEntries("Sections", report.Sections, opt.Top)
+		printSizeEntries("Symbols", report.Symbols, opt.Top)
+		return
+	}
+
+	baseline, err := objfile.ReadSizeReport(opt.Baseline)
+	if err != nil {
+		shell.Quit(fmt.Sprintf("Error reading baseline: %v", err))
+	}
+	diff := objfile.DiffSize(baseline, report)
+	if opt.JSON {
+		printJSON(shell, diff)
+	} else {
+		total := diff.Total
+		fmt.Printf("%s: %d -> %d bytes (%s)\n", report.Name, total.Old, total.New, formatSizeDelta(total))
+		printSizeDiffEntries("Objects", diff.Objects, opt.Top)
+		printSizeDiffEntries("Sections", diff.Sections, opt.Top)
+		printSizeDiffEntries("Symbols", diff.Symbols, opt.Top)
+	}
+
+	if opt.Threshold != "" {
+		exceeded, err := sizeThresholdExceeded(diff.Total, opt.Threshold)
+		if err != nil {
+			shell.Quit(err.Error())
+		}
+		if exceeded {
+			shell.Quit(fmt.Sprintf("❌ Size growth %s exceeds threshold %s", formatSizeDelta(diff.Total), opt.Threshold))
+		}
+		shell.Log(j9.LogLevelSuccess, fmt.Sprintf("✅ Size growth %s is within threshold %s", formatSizeDelta(diff.Total), opt.Threshold))
+	}
+}
+
+func sizeThresholdExceeded(total *objfile.SizeDiffEntry, threshold string) (bool, error) {
+	if percentStr, ok := strings.CutSuffix(threshold, "%"); ok {
+		percent, err := strconv.ParseFloat(percentStr, 64)
+		if err != nil {
+			return false, fmt.Errorf("invalid threshold %s: %w", threshold, err)
+		}
+		if total.Old == 0 {
+			return total.Delta > 0, nil
+		}
+		return float64(total.Delta)*100/float64(total.Old) > percent, nil
+	}
+	bytes, err := strconv.ParseInt(threshold, 10, 64)
+	if err != nil {
+		return false, fmt.Errorf("invalid threshold %s: %w", threshold, err)
+	}
+	return total.Delta > bytes, nil
+}
+
+func formatSizeDelta(e *objfile.SizeDiffEntry) string {
+	s := fmt.Sprintf("%+d bytes", e.Delta)
+	if e.Old > 0 {
+		s += fmt.Sprintf(", %+.2f%%", float64(e.Delta)*100/float64(e.Old))
+	}
+	return s
+}
+
+func printSizeEntries(title string, entries []*objfile.SizeEntry, top int) {
+	fmt.Printf("\n%s (%d):\n", title, len(entries))
+	for i, e := range entries {
+		if top > 0 && i >= top {
+			fmt.Printf("  ... and %d more\n", len(entries)-top)
+			break
+		}
+		fmt.Printf("  %12d  %s\n", e.Size, e.Name)
+	}
+}
+
+func printSizeDiffEntries(title string, entries []*objfile.SizeDiffEntry, top int) {
+	fmt.Printf("\n%s (%d changed):\n", title, len(entries))
+	for i, e := range entries {
+		if top > 0 && i >= top {
+			fmt.Printf("  ... and %d more\n", len(entries)-top)
+			break
+		}
+		fmt.Printf("  %+12d  %12d -> %-12d  %s\n", e.Delta, e.Old, e.New, e.Name)
+	}
+}
+
+func printJSON(shell *ku.Shell, v interface{}) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		shell.Quit(fmt.Sprintf("Error encoding JSON: %v", err))
+	}
+	fmt.Println(string(data))
+}
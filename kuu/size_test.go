@@ -0,0 +1,47 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/mgenware/ku-builder/objfile"
+)
+
+func TestSizeThresholdExceeded(t *testing.T) {
+	tests := []struct {
+		name      string
+		old       uint64
+		delta     int64
+		threshold string
+		want      bool
+		err       bool
+	}{
+		{name: "bytes within", old: 1000, delta: 100, threshold: "100"},
+		{name: "bytes exceeded", old: 1000, delta: 101, threshold: "100", want: true},
+		{name: "bytes shrink", old: 1000, delta: -500, threshold: "0"},
+		{name: "percent within", old: 1000, delta: 50, threshold: "5%"},
+		{name: "percent exceeded", old: 1000, delta: 51, threshold: "5%", want: true},
+		{name: "fractional percent", old: 1000, delta: 6, threshold: "0.5%", want: true},
+		{name: "percent of empty baseline", old: 0, delta: 1, threshold: "50%", want: true},
+		{name: "percent of empty baseline unchanged", old: 0, delta: 0, threshold: "50%"},
+		{name: "invalid bytes", threshold: "10kb", err: true},
+		{name: "invalid percent", threshold: "x%", err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			total := &objfile.SizeDiffEntry{Old: tt.old, New: uint64(int64(tt.old) + tt.delta), Delta: tt.delta}
+			got, err := sizeThresholdExceeded(total, tt.threshold)
+			if tt.err {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
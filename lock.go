@@ -0,0 +1,146 @@
+package ku
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/mgenware/j9/v3"
+	"github.com/mgenware/ku-builder/io2"
+)
+
+// Lock file name. Created in the working dir (next to `repo` and `build`) and meant to be committed.
+const LockFileName = "ku.lock"
+
+// Pins sources of repos whose ref moves (branch or no ref), and checksums of archives.
+type LockFile struct {
+	// K: repo name.
+	Repos map[string]*LockEntry `json:"repos"`
+}
+
+type LockEntry struct {
+	Url string `json:"url"`
+	// Branch of the repo, empty for the default branch.
+	Branch string `json:"branch,omitempty"`
+	// Resolved commit of git repos.
+	Commit string `json:"commit,omitempty"`
+	// SHA-256 of archives.
+	SHA256 string `json:"sha256,omitempty"`
+}
+
+func GetLockFilePath() string {
+	return globalLockFile
+}
+
+// Returns an empty lock file if it doesn't exist.
+func ReadLockFile(path string) (*LockFile, error) {
+	res := &LockFile{Repos: make(map[string]*LockEntry)}
+	if !io2.FileExists(path) {
+		return res, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, res); err != nil {
+		return nil, fmt.Errorf("error parsing lock file %s: %w", path, err)
+	}
+	if res.Repos == nil {
+		res.Repos = make(map[string]*LockEntry)
+	}
+	return res, nil
+}
+
+func (l *LockFile) Write(path string) error {
+	// Map keys are sorted by encoding/json.
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// Whether the repo is pinned by the lock file. Tags and commits are pinned by `RepoInfo` itself.
+func (r *RepoInfo) isLockable() bool {
+	return r.LocalRepoDir == "" && r.Url != "" && r.Tag == "" && r.Commit == ""
+}
+
+// Loaded once and shared by all builders of the process.
+var globalLock *LockFile
+
+func (bp *Builder) getLock() *LockFile {
+	if globalLock == nil {
+		lock, err := ReadLockFile(GetLockFilePath())
+		if err != nil {
+			bp.Shell.Quit(err.Error())
+		}
+		globalLock = lock
+	}
+	return globalLock
+}
+
+// Returns the lock entry of the repo, or nil if it's not pinned or `-update-lock` is set.
+// Quits if the entry doesn't match the repo.
+func (bp *Builder) getLockEntry() *LockEntry {
+	repo := bp.Repo
+	if !repo.isLockable() || bp.CLIArgs.UpdateLock {
+		return nil
+	}
+	entry := bp.getLock().Repos[repo.Name]
+	if entry == nil {
+		return nil
+	}
+	if entry.Url != repo.Url || entry.Branch != repo.Branch {
+		bp.Shell.Quit(fmt.Sprintf("%s entry of %s (url=%s, branch=%s) doesn't match the repo (url=%s, branch=%s). Run with -update-lock or `kuu lock update %s`.",
+			LockFileName, repo.Name, entry.Url, entry.Branch, repo.Url, repo.Branch, repo.Name))
+	}
+	return entry
+}
+
+func (bp *Builder) updateLockEntry(commit string, sha string) {
+	repo := bp.Repo
+	lock := bp.getLock()
+	entry := &LockEntry{
+		Url:    repo.Url,
+		Branch: repo.Branch,
+		Commit: commit,
+		SHA256: sha,
+	}
+	if old := lock.Repos[repo.Name]; old != nil && *old == *entry {
+		return
+	}
+	lock.Repos[repo.Name] = entry
+	if err := lock.Write(GetLockFilePath()); err != nil {
+		bp.Shell.Quit(fmt.Sprintf("Error writing %s: %v", LockFileName, err))
+	}
+	pin := commit
+	if pin == "" {
+		pin = "sha256:" + sha
+	}
+	bp.Shell.Log(j9.LogLevelInfo, fmt.Sprintf("🔒 Pinned %s to %s in %s", repo.Name, pin, LockFileName))
+}
+
+// Pins the current HEAD of `repoDir`.
+func (bp *Builder) lockRepoHead(repoDir string) {
+	if !bp.Repo.isLockable() {
+		return
+	}
+	bp.updateLockEntry(gitHead(bp.Shell, repoDir), "")
+}
+
+// Verifies the downloaded archive against the lock file, or pins its checksum if not pinned.
+func (bp *Builder) lockArchive(file string) {
+	hash, err := FileHash(file)
+	if err != nil {
+		bp.Shell.Quit(fmt.Sprintf("Error hashing %s: %v", file, err))
+	}
+	sha := hex.EncodeToString(hash)
+	if entry := bp.getLockEntry(); entry != nil && entry.SHA256 != "" {
+		if entry.SHA256 != sha {
+			bp.Shell.Quit(fmt.Sprintf("SHA-256 of %s is %s, but %s pins %s. Run with -update-lock if the change is expected.", bp.Repo.Url, sha, LockFileName, entry.SHA256))
+		}
+		return
+	}
+	bp.updateLockEntry("", sha)
+}
@@ -0,0 +1,144 @@
+package ku
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mgenware/j9/v3"
+	"github.com/mgenware/ku-builder/io2"
+	"github.com/mgenware/ku-builder/objfile"
+)
+
+type MergeStaticLibsOptions struct {
+	Shell *Shell
+	// Required. Paths of static libs to merge.
+	Inputs []string
+	// Required. Path of the merged static lib.
+	Output string
+	// If true, merge with `libtool -static`. Otherwise, merge with `llvm-ar qcL`.
+	Darwin bool
+	// Path of libtool (Darwin) or llvm-ar (others).
+	// Defaults to `libtool` on Darwin. Required for others.
+	Tool string
+}
+
+// Merges static libs into a single archive. Duplicate object names across (or inside) archives
+// don't overwrite each other: libtool gets extracted object files, and `llvm-ar q` appends without replacing.
+func MergeStaticLibs(opt *MergeStaticLibsOptions) {
+	if opt == nil || opt.Shell == nil {
+		fmt.Printf("MergeStaticLibs: options and shell are required\n")
+		os.Exit(1)
+	}
+	shell := opt.Shell
+	if len(opt.Inputs) == 0 || opt.Output == "" {
+		shell.Quit("MergeStaticLibs: Inputs and Output are required")
+	}
+	tool := opt.Tool
+	if tool == "" {
+		if !opt.Darwin {
+			shell.Quit("MergeStaticLibs: Tool (llvm-ar path) is required for non-Darwin libs")
+		}
+		tool = "libtool"
+	}
+
+	output := io2.ResolvePath(opt.Output)
+	io2.Mkdirp(filepath.Dir(output))
+	// Both libtool and ar append to existing archives.
+	if err := os.RemoveAll(output); err != nil {
+		shell.Quit(fmt.Sprintf("Error removing existing file %s: %v", output, err))
+	}
+
+	if opt.Darwin {
+		tmpDir, err := os.MkdirTemp("", "ku_merge")
+		if err != nil {
+			shell.Quit(fmt.Sprintf("Error creating temp dir: %v", err))
+		}
+		defer os.RemoveAll(tmpDir)
+
+		objFiles := extractArchiveMembers(shell, opt.Inputs, tmpDir)
+		// Use a file list to avoid exceeding the max command line length.
+		fileList := filepath.Join(tmpDir, "filelist.txt")
+		if err := os.WriteFile(fileList, []byte(strings.Join(objFiles, "\n")+"\n"), 0644); err != nil {
+			shell.Quit(fmt.Sprintf("Error writing %s: %v", fileList, err))
+		}
+		shell.Spawn(&j9.SpawnOpt{
+			Name: tool,
+			Args: []string{"-static", "-o", output, "-filelist", fileList},
+		})
+	} else {
+		// `L` appends members of the input archives instead of the archives themselves.
+		args := []string{"qcL", output}
+		for _, input := range opt.Inputs {
+			args = append(args, io2.ResolvePath(input))
+		}
+		shell.Spawn(&j9.SpawnOpt{
+			Name: tool,
+			Args: args,
+		})
+	}
+	shell.Log(j9.LogLevelSuccess, fmt.Sprintf("✅ Merged %d lib(s) into %s", len(opt.Inputs), output))
+}
+
+// Extracts object files of all archives to `dir` and returns their paths in link order.
+// Duplicate names are prefixed with the archive name and a counter.
+func extractArchiveMembers(shell *Shell, inputs []string, dir string) []string {
+	var files []string
+	used := make(map[string]bool)
+	for _, input := range inputs {
+		members, err := objfile.ReadArchive(input)
+		if err != nil {
+			shell.Quit(fmt.Sprintf("Error reading archive: %v", err))
+		}
+		libName := strings.TrimSuffix(filepath.Base(input), filepath.Ext(input))
+		for _, m := range members {
+			name := filepath.Base(m.Name)
+			for i := 1; used[name]; i++ {
+				name = fmt.Sprintf("%s_%d_%s", libName, i, filepath.Base(m.Name))
+			}
+			used[name] = true
+
+			file := filepath.Join(dir, name)
+			if err := os.WriteFile(file, m.Data, 0644); err != nil {
+				shell.Quit(fmt.Sprintf("Error writing %s: %v", file, err))
+			}
+			files = append(files, file)
+		}
+	}
+	return files
+}
+
+// Merges static libs in OutLibDir (names without extension, e.g. libavcodec) into `<OutLibDir>/<outName>.a`,
+// or `<DistLibDir>/<outName>.a` if `dist` is true. Returns the path of the merged lib.
+func (be *BuildEnv) MergeStaticLibs(outName string, libNames []string, dist bool) string {
+	e := be.OSEnv
+	var inputs []string
+	for _, lib := range libNames {
+		inputs = append(inputs, be.getStaticLibPath(lib))
+	}
+
+	outDir := be.OutLibDir
+	if dist {
+		if be.DistLibDir == "" {
+			be.Shell.Quit("Dist dir is not enabled, set `CLIOptions.CreateDistDir` to true")
+		}
+		outDir = be.DistLibDir
+	}
+	output := filepath.Join(outDir, outName+".a")
+
+	var tool string
+	if e.IsDarwinPlatform() {
+		tool = e.RunXcodeFindCached("libtool")
+	} else {
+		tool = e.GetNDKToolchainBinPath("llvm-ar")
+	}
+	MergeStaticLibs(&MergeStaticLibsOptions{
+		Shell:  be.Shell,
+		Inputs: inputs,
+		Output: output,
+		Darwin: e.IsDarwinPlatform(),
+		Tool:   tool,
+	})
+	return output
+}
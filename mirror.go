@@ -0,0 +1,236 @@
+package ku
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/mgenware/j9/v3"
+	"github.com/mgenware/ku-builder/io2"
+)
+
+// Env var of the local source mirror dir, which contains bare git repos and archives keyed by URL.
+// Populated by `kuu mirror sync` and used by `-offline` builds.
+const MirrorDirEnv = "KU_MIRROR_DIR"
+
+// Sources manifest in the mirror dir. Sources fetched by builds are recorded here when `KU_MIRROR_DIR` is set.
+const MirrorSourcesFile = "sources.json"
+
+type MirrorSource struct {
+	Url string `json:"url"`
+	// Whether the URL points to an archive instead of a git repo.
+	Archive bool `json:"archive,omitempty"`
+}
+
+type MirrorSources struct {
+	Sources []MirrorSource `json:"sources"`
+}
+
+// Returns an absolute path, or an empty string if `KU_MIRROR_DIR` is not set.
+func GetMirrorDir() string {
+	dir := os.Getenv(MirrorDirEnv)
+	if dir == "" {
+		return ""
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return dir
+	}
+	return abs
+}
+
+var mirrorKeyInvalidChars = regexp.MustCompile(`[^A-Za-z0-9._/-]`)
+
+// Converts a URL to a relative path, e.g. `https://github.com/madler/zlib.git` -> `github.com/madler/zlib`.
+func mirrorKey(url string, archive bool) string {
+	key := url
+	if i := strings.Index(key, "://"); i >= 0 {
+		key = key[i+3:]
+	}
+	if i := strings.IndexAny(key, "?#"); i >= 0 {
+		key = key[:i]
+	}
+	// user@host:path (scp-like git URLs).
+	if i := strings.Index(key, "@"); i >= 0 && i < strings.Index(key+"/", "/") {
+		key = key[i+1:]
+	}
+	key = strings.Replace(key, ":", "/", 1)
+	if !archive {
+		key = strings.TrimSuffix(strings.TrimSuffix(key, "/"), ".git")
+	}
+	key = mirrorKeyInvalidChars.ReplaceAllString(key, "_")
+
+	var parts []string
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			continue
+		}
+		parts = append(parts, part)
+	}
+	return filepath.Join(parts...)
+}
+
+// Returns the path of the source in the mirror dir.
+// Git repos: <mirror>/git/<key>.git, archives: <mirror>/archives/<key>.
+func GetMirrorSourcePath(mirrorDir string, src MirrorSource) string {
+	if src.Archive {
+		return filepath.Join(mirrorDir, "archives", mirrorKey(src.Url, true))
+	}
+	return filepath.Join(mirrorDir, "git", mirrorKey(src.Url, false)+".git")
+}
+
+func (src MirrorSource) String() string {
+	if src.Archive {
+		return src.Url + " (archive)"
+	}
+	return src.Url
+}
+
+// Returns nil for local repos.
+func (r *RepoInfo) MirrorSource() *MirrorSource {
+	if r.LocalRepoDir != "" || r.Url == "" {
+		return nil
+	}
+	return &MirrorSource{Url: r.Url, Archive: r.UrlArchiveName != ""}
+}
+
+// Returns an empty list if the sources manifest doesn't exist.
+func ReadMirrorSources(mirrorDir string) (*MirrorSources, error) {
+	path := filepath.Join(mirrorDir, MirrorSourcesFile)
+	res := &MirrorSources{}
+	if !io2.FileExists(path) {
+		return res, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, res); err != nil {
+		return nil, fmt.Errorf("error parsing mirror sources %s: %w", path, err)
+	}
+	return res, nil
+}
+
+// Adds sources to the sources manifest of the mirror dir. The manifest is only written if anything changed.
+func AddMirrorSources(mirrorDir string, sources []MirrorSource) error {
+	manifest, err := ReadMirrorSources(mirrorDir)
+	if err != nil {
+		return err
+	}
+	existing := make(map[MirrorSource]bool)
+	for _, src := range manifest.Sources {
+		existing[src] = true
+	}
+	changed := false
+	for _, src := range sources {
+		if !existing[src] {
+			existing[src] = true
+			manifest.Sources = append(manifest.Sources, src)
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	sort.Slice(manifest.Sources, func(i, j int) bool {
+		return manifest.Sources[i].Url < manifest.Sources[j].Url
+	})
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	io2.Mkdirp(mirrorDir)
+	return os.WriteFile(filepath.Join(mirrorDir, MirrorSourcesFile), append(data, '\n'), 0644)
+}
+
+// Returns sources not found in the mirror dir.
+func MissingMirrorSources(mirrorDir string, sources []MirrorSource) []MirrorSource {
+	var missing []MirrorSource
+	for _, src := range sources {
+		path := GetMirrorSourcePath(mirrorDir, src)
+		if src.Archive && !io2.FileExists(path) || !src.Archive && !io2.DirectoryExists(path) {
+			missing = append(missing, src)
+		}
+	}
+	return missing
+}
+
+// Quits with a list of missing sources if any of them is not in the mirror.
+func CheckOfflineSources(shell *Shell, sources []MirrorSource) {
+	mirrorDir := GetMirrorDir()
+	if mirrorDir == "" {
+		shell.Quit(fmt.Sprintf("Offline mode requires a mirror dir, set %s.", MirrorDirEnv))
+	}
+	missing := MissingMirrorSources(mirrorDir, sources)
+	if len(missing) == 0 {
+		return
+	}
+	lines := []string{fmt.Sprintf("%d source(s) not found in mirror %s:", len(missing), mirrorDir)}
+	for _, src := range missing {
+		lines = append(lines, "  "+src.String())
+	}
+	lines = append(lines, "Run `kuu mirror sync` on a machine with network access and copy the mirror dir.")
+	shell.Quit(strings.Join(lines, "\n"))
+}
+
+// Fetches sources into the mirror dir. Git repos are mirrored (`git clone --mirror`) and updated
+// if they exist. Archives are only downloaded once.
+func SyncMirror(shell *Shell, mirrorDir string, sources []MirrorSource) {
+	for _, src := range sources {
+		path := GetMirrorSourcePath(mirrorDir, src)
+		if src.Archive {
+			if io2.FileExists(path) {
+				shell.Log(j9.LogLevelVerbose, "Archive already mirrored: "+src.Url)
+				continue
+			}
+			io2.Mkdirp(filepath.Dir(path))
+			tmpPath := path + ".download"
+			shell.Spawn(&j9.SpawnOpt{
+				Name: "curl",
+				Args: []string{"-fL", "-o", tmpPath, src.Url},
+			})
+			if err := os.Rename(tmpPath, path); err != nil {
+				shell.Quit(fmt.Sprintf("Error moving %s to %s: %v", tmpPath, path, err))
+			}
+		} else if io2.DirectoryExists(path) {
+			shell.Spawn(&j9.SpawnOpt{
+				Name: "git",
+				Args: []string{"--git-dir", path, "remote", "update", "--prune"},
+			})
+		} else {
+			io2.Mkdirp(filepath.Dir(path))
+			shell.Spawn(&j9.SpawnOpt{
+				Name: "git",
+				Args: []string{"clone", "--mirror", src.Url, path},
+			})
+		}
+		shell.Log(j9.LogLevelSuccess, "✅ Mirrored "+src.String())
+	}
+}
+
+// Returns the URL to fetch the repo from. In offline mode, this is the mirrored source and quits if it's missing.
+func (bp *Builder) resolveRepoFetchUrl() string {
+	repo := bp.Repo
+	src := repo.MirrorSource()
+	if src == nil || !bp.CLIArgs.Offline {
+		return repo.Url
+	}
+	CheckOfflineSources(bp.Shell, []MirrorSource{*src})
+	return "file://" + GetMirrorSourcePath(GetMirrorDir(), *src)
+}
+
+// Records a source (e.g. `RepoInfo.MirrorSource()` or a submodule) in the mirror sources manifest
+// if `KU_MIRROR_DIR` is set, so `kuu mirror sync` picks it up.
+func (bp *Builder) recordMirrorSource(src *MirrorSource) {
+	mirrorDir := GetMirrorDir()
+	if mirrorDir == "" || src == nil || bp.CLIArgs.Offline {
+		return
+	}
+	if err := AddMirrorSources(mirrorDir, []MirrorSource{*src}); err != nil {
+		bp.Shell.Quit(fmt.Sprintf("Error recording mirror source: %v", err))
+	}
+}
@@ -0,0 +1,114 @@
+package objfile
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const arMagic = "!<arch>\n"
+const arHeaderSize = 60
+
+// ArMember is a member file of a Unix ar archive (static library).
+type ArMember struct {
+	Name string
+	Data []byte
+}
+
+// Symbol table members written by ar/ranlib/libtool. They are not object files.
+var arSymbolTableNames = map[string]bool{
+	"/":                   true,
+	"/SYM64/":             true,
+	"__.SYMDEF":           true,
+	"__.SYMDEF SORTED":    true,
+	"__.SYMDEF_64":        true,
+	"__.SYMDEF_64 SORTED": true,
+}
+
+func IsArchive(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(arMagic))
+}
+
+func ReadArchive(path string) ([]*ArMember, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	members, err := ParseArchive(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse archive %s: %w", path, err)
+	}
+	return members, nil
+}
+
+// Parses both BSD (Darwin) and GNU/SysV (llvm-ar on Android) archives. Symbol tables are skipped.
+func ParseArchive(data []byte) ([]*ArMember, error) {
+	if !IsArchive(data) {
+		return nil, fmt.Errorf("not an ar archive")
+	}
+
+	var members []*ArMember
+	// GNU long name table ("//" member).
+	var longNames []byte
+	pos := len(arMagic)
+	for pos < len(data) {
+		if pos+arHeaderSize > len(data) {
+			return nil, fmt.Errorf("truncated member header at offset %d", pos)
+		}
+		header := data[pos : pos+arHeaderSize]
+		if string(header[58:60]) != "`\n" {
+			return nil, fmt.Errorf("invalid member header at offset %d", pos)
+		}
+		name := strings.TrimRight(string(header[0:16]), " ")
+		size, err := strconv.ParseInt(strings.TrimSpace(string(header[48:58])), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid member size at offset %d: %w", pos, err)
+		}
+		if size < 0 {
+			return nil, fmt.Errorf("negative member size %d at offset %d", size, pos)
+		}
+		pos += arHeaderSize
+		// Compared in int64 so huge sizes can't overflow.
+		if size > int64(len(data)-pos) {
+			return nil, fmt.Errorf("truncated member %s at offset %d", name, pos)
+		}
+		body := data[pos : pos+int(size)]
+		// Members are aligned to 2 bytes.
+		pos += int(size) + int(size%2)
+
+		switch {
+		case strings.HasPrefix(name, "#1/"):
+			// BSD extended name: the name is stored at the start of the member data.
+			nameLen, err := strconv.Atoi(name[3:])
+			if err != nil || nameLen < 0 || nameLen > len(body) {
+				return nil, fmt.Errorf("invalid BSD member name %s", name)
+			}
+			name = strings.TrimRight(string(body[:nameLen]), "\x00")
+			body = body[nameLen:]
+		case name == "//":
+			longNames = body
+			continue
+		case len(name) > 1 && name[0] == '/' && name[1] >= '0' && name[1] <= '9':
+			// GNU long name: offset into the long name table.
+			offset, err := strconv.Atoi(name[1:])
+			if err != nil || offset >= len(longNames) {
+				return nil, fmt.Errorf("invalid GNU member name %s", name)
+			}
+			end := bytes.IndexByte(longNames[offset:], '\n')
+			if end == -1 {
+				end = len(longNames) - offset
+			}
+			name = strings.TrimSuffix(string(longNames[offset:offset+end]), "/")
+		case !arSymbolTableNames[name]:
+			name = strings.TrimSuffix(name, "/")
+		}
+
+		if arSymbolTableNames[name] {
+			continue
+		}
+		members = append(members, &ArMember{Name: name, Data: body})
+	}
+	return members, nil
+}
@@ -0,0 +1,67 @@
+package objfile
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func arHeader(name string, size string) string {
+	return fmt.Sprintf("%-16s%-12s%-6s%-6s%-8s%-10s`\n", name, "0", "0", "0", "644", size)
+}
+
+func TestParseArchive(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		members []string
+		err     string
+	}{
+		{
+			name:    "normal",
+			data:    arMagic + arHeader("a.o/", "3") + "abc\n" + arHeader("#1/8", "10") + "b.o\x00\x00\x00\x00\x00xy",
+			members: []string{"a.o:abc", "b.o:xy"},
+		},
+		{
+			name: "truncated member",
+			data: arMagic + arHeader("a.o/", "100") + "abc",
+			err:  "truncated member a.o",
+		},
+		{
+			name: "truncated header",
+			data: arMagic + arHeader("a.o/", "1")[:30],
+			err:  "truncated member header",
+		},
+		{
+			name: "negative size",
+			data: arMagic + arHeader("a.o/", "-100") + "abc",
+			err:  "negative member size",
+		},
+		{
+			name: "negative BSD name length",
+			data: arMagic + arHeader("#1/-5", "2") + "ab",
+			err:  "invalid BSD member name",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			members, err := ParseArchive([]byte(tt.data))
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("expected error containing %q, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, m := range members {
+				got = append(got, m.Name+":"+string(m.Data))
+			}
+			if strings.Join(got, ",") != strings.Join(tt.members, ",") {
+				t.Fatalf("expected %v, got %v", tt.members, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package objfile
+
+import (
+	"debug/elf"
+	"debug/macho"
+	"encoding/hex"
+	"fmt"
+	"os"
+)
+
+const machoLoadCmdUUID macho.LoadCmd = 0x1b
+const machoStabOSO = 0x66
+
+// Returns the LC_UUID of a Mach-O file or the GNU build ID of an ELF file as a hex string.
+// Returns an empty string if the file has no such ID.
+func ReadBuildID(path string) (string, error) {
+	format, err := DetectFormat(path)
+	if err != nil {
+		return "", err
+	}
+	switch format {
+	case FormatMachO:
+		f, err := macho.Open(path)
+		if err != nil {
+			return "", err
+		}
+		defer f.Close()
+		for _, load := range f.Loads {
+			raw := load.Raw()
+			if len(raw) >= 24 && f.ByteOrder.Uint32(raw[0:4]) == uint32(machoLoadCmdUUID) {
+				return hex.EncodeToString(raw[8:24]), nil
+			}
+		}
+		return "", nil
+	case FormatELF:
+		f, err := elf.Open(path)
+		if err != nil {
+			return "", err
+		}
+		defer f.Close()
+		sec := f.Section(".note.gnu.build-id")
+		if sec == nil {
+			return "", nil
+		}
+		data, err := sec.Data()
+		if err != nil {
+			return "", err
+		}
+		// Note layout: namesz(4), descsz(4), type(4), name (aligned to 4), desc.
+		if len(data) < 12 {
+			return "", fmt.Errorf("invalid build ID note in %s", path)
+		}
+		nameSize := int(f.ByteOrder.Uint32(data[0:4]))
+		descSize := int(f.ByteOrder.Uint32(data[4:8]))
+		descStart := 12 + (nameSize+3)&^3
+		if descStart+descSize > len(data) {
+			return "", fmt.Errorf("invalid build ID note in %s", path)
+		}
+		return hex.EncodeToString(data[descStart : descStart+descSize]), nil
+	}
+	return "", fmt.Errorf("unsupported file format %s: %s", format, path)
+}
+
+// Returns true if the file still contains debug info that can be extracted.
+// For Mach-O, this checks for DWARF sections or a debug map (N_OSO stabs pointing to object files).
+// For ELF, this checks for DWARF sections.
+func HasDebugInfo(path string) (bool, error) {
+	format, err := DetectFormat(path)
+	if err != nil {
+		return false, err
+	}
+	switch format {
+	case FormatMachO:
+		f, err := macho.Open(path)
+		if err != nil {
+			return false, err
+		}
+		defer f.Close()
+		if f.Section("__debug_info") != nil {
+			return true, nil
+		}
+		if f.Symtab == nil {
+			return false, nil
+		}
+		for _, sym := range f.Symtab.Syms {
+			if sym.Type == machoStabOSO {
+				return true, nil
+			}
+		}
+		return false, nil
+	case FormatELF:
+		f, err := elf.Open(path)
+		if err != nil {
+			return false, err
+		}
+		defer f.Close()
+		return f.Section(".debug_info") != nil, nil
+	}
+	return false, fmt.Errorf("unsupported file format %s: %s", format, path)
+}
+
+// Reads the first bytes of a file to detect its format.
+func DetectFormat(path string) (Format, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	header := make([]byte, 8)
+	n, err := f.Read(header)
+	if err != nil {
+		return "", err
+	}
+	return DetectFormatFromBytes(header[:n]), nil
+}
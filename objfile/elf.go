@@ -0,0 +1,91 @@
+package objfile
+
+import (
+	"debug/elf"
+	"fmt"
+)
+
+func elfArchName(machine elf.Machine) string {
+	switch machine {
+	case elf.EM_AARCH64:
+		return "arm64"
+	case elf.EM_X86_64:
+		return "x86_64"
+	case elf.EM_ARM:
+		return "arm"
+	case elf.EM_386:
+		return "x86"
+	}
+	return machine.String()
+}
+
+func elfTypeName(t elf.Type) string {
+	switch t {
+	case elf.ET_REL:
+		return "object"
+	case elf.ET_EXEC:
+		return "executable"
+	case elf.ET_DYN:
+		return "shared"
+	}
+	return t.String()
+}
+
+// Returns the API level from the `.note.android.ident` note, or 0 for non-Android files.
+func elfAndroidAPILevel(f *elf.File) uint32 {
+	sec := f.Section(".note.android.ident")
+	if sec == nil {
+		return 0
+	}
+	data, err := sec.Data()
+	// Note layout: namesz(4), descsz(4), type(4), name "Android\0" (aligned to 4), desc (API level first).
+	if err != nil || len(data) < 12 {
+		return 0
+	}
+	nameSize := int(f.ByteOrder.Uint32(data[0:4]))
+	descStart := 12 + (nameSize+3)&^3
+	if descStart+4 > len(data) {
+		return 0
+	}
+	return f.ByteOrder.Uint32(data[descStart : descStart+4])
+}
+
+func inspectELF(f *elf.File, r *Report) {
+	r.Arch = elfArchName(f.Machine)
+	r.Type = elfTypeName(f.Type)
+	if api := elfAndroidAPILevel(f); api != 0 {
+		r.Platform = "android"
+		r.MinOS = fmt.Sprintf("%d", api)
+	} else {
+		r.Platform = "linux"
+	}
+
+	if f.Type == elf.ET_DYN {
+		if soNames, err := f.DynString(elf.DT_SONAME); err == nil && len(soNames) > 0 {
+			r.SOName = soNames[0]
+		}
+		for _, tag := range []elf.DynTag{elf.DT_RUNPATH, elf.DT_RPATH} {
+			if paths, err := f.DynString(tag); err == nil {
+				r.Rpaths = append(r.Rpaths, paths...)
+			}
+		}
+		if libs, err := f.ImportedLibraries(); err == nil {
+			r.Dependencies = libs
+		}
+	}
+
+	for _, sec := range f.Sections {
+		if sec.Type == elf.SHT_NULL {
+			continue
+		}
+		// `.bss` and similar sections don't take file space.
+		if sec.Type == elf.SHT_NOBITS {
+			continue
+		}
+		r.addSection(sec.Name, sec.Size)
+	}
+	set := make(map[string]bool)
+	// Errors are ignored since symbol tables are optional (e.g. stripped files).
+	_ = collectELFExportedSymbols(f, set)
+	r.ExportedSymbols = len(set)
+}
@@ -0,0 +1,58 @@
+package objfile
+
+import (
+	"debug/macho"
+	"encoding/binary"
+	"fmt"
+)
+
+const (
+	fatMagic   = 0xcafebabe
+	fatMagic64 = 0xcafebabf
+)
+
+// An arch slice of a fat file. Slices are thin Mach-O files or ar archives (fat static libs).
+type FatSlice struct {
+	Cpu  macho.Cpu
+	Data []byte
+}
+
+// Splits a fat file into its slices. Unlike `macho.NewFatFile`, slices are not required to be Mach-O files.
+func ParseFat(data []byte) ([]*FatSlice, error) {
+	if len(data) < 8 {
+		return nil, fmt.Errorf("truncated fat header")
+	}
+	magic := binary.BigEndian.Uint32(data[0:4])
+	if magic != fatMagic && magic != fatMagic64 {
+		return nil, fmt.Errorf("invalid fat magic %#x", magic)
+	}
+	// fat_arch: cputype, cpusubtype, offset, size, align (all uint32).
+	// fat_arch_64: offset and size are uint64, followed by a reserved uint32.
+	entrySize := 20
+	if magic == fatMagic64 {
+		entrySize = 32
+	}
+	count := int(binary.BigEndian.Uint32(data[4:8]))
+	if count > (len(data)-8)/entrySize {
+		return nil, fmt.Errorf("truncated fat header")
+	}
+
+	slices := make([]*FatSlice, 0, count)
+	for i := range count {
+		entry := data[8+i*entrySize:]
+		cpu := macho.Cpu(binary.BigEndian.Uint32(entry[0:4]))
+		var offset, size uint64
+		if magic == fatMagic64 {
+			offset = binary.BigEndian.Uint64(entry[8:16])
+			size = binary.BigEndian.Uint64(entry[16:24])
+		} else {
+			offset = uint64(binary.BigEndian.Uint32(entry[8:12]))
+			size = uint64(binary.BigEndian.Uint32(entry[12:16]))
+		}
+		if offset > uint64(len(data)) || size > uint64(len(data))-offset {
+			return nil, fmt.Errorf("truncated %s slice at offset %d", machoArchName(cpu), offset)
+		}
+		slices = append(slices, &FatSlice{Cpu: cpu, Data: data[offset : offset+size]})
+	}
+	return slices, nil
+}
@@ -0,0 +1,134 @@
+package objfile
+
+import (
+	"bytes"
+	"debug/macho"
+	"encoding/binary"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+// Returns a 64-bit Mach-O object with a `__TEXT,__text` section of `textSize` bytes defining the external symbol `sym`.
+func testMachO(cpu macho.Cpu, sym string, textSize int) []byte {
+	const (
+		headerSize  = 32
+		segmentSize = 72 + 80
+		symtabSize  = 24
+	)
+	textOff := headerSize + segmentSize + symtabSize
+	symOff := textOff + textSize
+	strTab := "\x00_" + sym + "\x00"
+	strOff := symOff + 16
+
+	var b bytes.Buffer
+	w := func(v any) { binary.Write(&b, binary.LittleEndian, v) }
+	name := func(s string) { b.Write(append([]byte(s), make([]byte, 16-len(s))...)) }
+
+	// mach_header_64
+	w([]uint32{0xfeedfacf, uint32(cpu), 0, uint32(macho.TypeObj), 2, segmentSize + symtabSize, 0, 0})
+	// segment_command_64 with one section_64
+	w([]uint32{uint32(macho.LoadCmdSegment64), segmentSize})
+	name("")
+	w([]uint64{0, uint64(textSize), uint64(textOff), uint64(textSize)})
+	w([]uint32{7, 7, 1, 0})
+	name("__text")
+	name("__TEXT")
+	w([]uint64{0, uint64(textSize)})
+	w([]uint32{uint32(textOff), 0, 0, 0, 0x80000400, 0, 0, 0})
+	// symtab_command
+	w([]uint32{uint32(macho.LoadCmdSymtab), symtabSize, uint32(symOff), 1, uint32(strOff), uint32(len(strTab))})
+
+	b.Write(make([]byte, textSize))
+	// nlist_64: N_SECT | N_EXT in section 1.
+	w(uint32(1))
+	w([]uint8{0x0f, 1})
+	w(uint16(0))
+	w(uint64(0))
+	b.WriteString(strTab)
+	return b.Bytes()
+}
+
+// Returns an ar archive of `members` (name and data pairs).
+func testArchive(members ...any) []byte {
+	var b bytes.Buffer
+	b.WriteString(arMagic)
+	for i := 0; i < len(members); i += 2 {
+		data := members[i+1].([]byte)
+		b.WriteString(arHeader(members[i].(string)+"/", strconv.Itoa(len(data))))
+		b.Write(data)
+		if len(data)%2 == 1 {
+			b.WriteByte('\n')
+		}
+	}
+	return b.Bytes()
+}
+
+// Returns a fat file of `slices`.
+func testFat(slices ...*FatSlice) []byte {
+	var b bytes.Buffer
+	w := func(v any) { binary.Write(&b, binary.BigEndian, v) }
+	w([]uint32{fatMagic, uint32(len(slices))})
+	offset := 8 + 20*len(slices)
+	for _, s := range slices {
+		w([]uint32{uint32(s.Cpu), 0, uint32(offset), uint32(len(s.Data)), 0})
+		offset += len(s.Data)
+	}
+	for _, s := range slices {
+		b.Write(s.Data)
+	}
+	return b.Bytes()
+}
+
+func testFatArchive() []byte {
+	return testFat(
+		&FatSlice{Cpu: macho.CpuArm64, Data: testArchive("a.o", testMachO(macho.CpuArm64, "foo", 8), "b.o", testMachO(macho.CpuArm64, "bar", 4))},
+		&FatSlice{Cpu: macho.CpuAmd64, Data: testArchive("a.o", testMachO(macho.CpuAmd64, "foo", 16), "c.o", testMachO(macho.CpuAmd64, "baz", 4))},
+	)
+}
+
+func TestParseFat(t *testing.T) {
+	arm64 := testMachO(macho.CpuArm64, "foo", 8)
+	archive := testArchive("a.o", arm64)
+	data := testFat(&FatSlice{Cpu: macho.CpuArm64, Data: arm64}, &FatSlice{Cpu: macho.CpuAmd64, Data: archive})
+	got, err := ParseFat(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].Cpu != macho.CpuArm64 || !bytes.Equal(got[0].Data, arm64) ||
+		got[1].Cpu != macho.CpuAmd64 || !bytes.Equal(got[1].Data, archive) {
+		t.Fatalf("unexpected slices %v", got)
+	}
+
+	if _, err := ParseFat(data[:len(data)-1]); err == nil {
+		t.Fatal("expected error of truncated slice")
+	}
+	if _, err := ParseFat(data[:12]); err == nil {
+		t.Fatal("expected error of truncated header")
+	}
+}
+
+func TestReadExportedSymbolsFatArchive(t *testing.T) {
+	got, err := ReadExportedSymbolsFromBytes(testFatArchive())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"bar", "baz", "foo"}; !slices.Equal(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestInspectFatArchive(t *testing.T) {
+	r, err := InspectBytes("libfoo.a", testFatArchive())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Format != FormatFat || !slices.Equal(r.Archs, []string{"arm64", "x86_64"}) || len(r.Children) != 2 {
+		t.Fatalf("unexpected report %+v", r)
+	}
+	for _, child := range r.Children {
+		if child.Format != FormatArchive || child.Type != "static" || child.Members != 2 || child.ExportedSymbols != 2 {
+			t.Fatalf("unexpected %s slice %+v", child.Name, child)
+		}
+	}
+}
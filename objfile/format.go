@@ -0,0 +1,41 @@
+package objfile
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+type Format string
+
+const (
+	FormatUnknown Format = "unknown"
+	FormatMachO   Format = "mach-o"
+	FormatFat     Format = "fat"
+	FormatELF     Format = "elf"
+	FormatArchive Format = "ar"
+	// Container formats, detected by `Inspect`.
+	FormatXCFramework Format = "xcframework"
+	FormatAAR         Format = "aar"
+)
+
+func DetectFormatFromBytes(data []byte) Format {
+	if IsArchive(data) {
+		return FormatArchive
+	}
+	if bytes.HasPrefix(data, []byte("\x7fELF")) {
+		return FormatELF
+	}
+	if len(data) < 4 {
+		return FormatUnknown
+	}
+	magicLE := binary.LittleEndian.Uint32(data[0:4])
+	magicBE := binary.BigEndian.Uint32(data[0:4])
+	switch {
+	case magicLE == 0xfeedface || magicLE == 0xfeedfacf || magicBE == 0xfeedface || magicBE == 0xfeedfacf:
+		return FormatMachO
+	case magicBE == 0xcafebabe || magicBE == 0xcafebabf:
+		// Note: Java class files share the same magic, but they are not expected here.
+		return FormatFat
+	}
+	return FormatUnknown
+}
@@ -0,0 +1,249 @@
+package objfile
+
+import (
+	"archive/zip"
+	"bytes"
+	"debug/elf"
+	"debug/macho"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mgenware/ku-builder/io2"
+)
+
+type SectionSize struct {
+	Name string `json:"name"`
+	Size uint64 `json:"size"`
+}
+
+// Binary report of a file or container. Containers (fat, xcframework, AAR) list their binaries in `Children`.
+// Archives (ar) are aggregated from their members.
+type Report struct {
+	// File path, fat arch, xcframework library identifier or AAR entry.
+	Name   string `json:"name"`
+	Format Format `json:"format"`
+	// Example: dylib, shared, object, executable.
+	Type string `json:"type,omitempty"`
+	Arch string `json:"arch,omitempty"`
+	// Architectures of containers and archives.
+	Archs []string `json:"archs,omitempty"`
+	// Example: ios, ios-simulator, android.
+	Platform string `json:"platform,omitempty"`
+	// Min OS version (Darwin) or API level (Android).
+	MinOS      string `json:"minOS,omitempty"`
+	SDKVersion string `json:"sdkVersion,omitempty"`
+
+	InstallName     string         `json:"installName,omitempty"`
+	SOName          string         `json:"soname,omitempty"`
+	Rpaths          []string       `json:"rpaths,omitempty"`
+	Dependencies    []string       `json:"dependencies,omitempty"`
+	ExportedSymbols int            `json:"exportedSymbols"`
+	CodeSigned      bool           `json:"codeSigned"`
+	Sections        []*SectionSize `json:"sections,omitempty"`
+
+	// Number of object files in an archive.
+	Members  int       `json:"members,omitempty"`
+	Children []*Report `json:"children,omitempty"`
+}
+
+func (r *Report) addSection(name string, size uint64) {
+	for _, sec := range r.Sections {
+		if sec.Name == name {
+			sec.Size += size
+			return
+		}
+	}
+	r.Sections = append(r.Sections, &SectionSize{Name: name, Size: size})
+}
+
+func (r *Report) addArch(arch string) {
+	for _, a := range r.Archs {
+		if a == arch {
+			return
+		}
+	}
+	r.Archs = append(r.Archs, arch)
+}
+
+// Inspects a Mach-O (thin or fat), ELF, ar archive, xcframework or AAR file.
+func Inspect(path string) (*Report, error) {
+	if io2.DirectoryExists(path) {
+		if strings.HasSuffix(strings.TrimSuffix(path, "/"), ".xcframework") {
+			return inspectXCFramework(path)
+		}
+		return nil, fmt.Errorf("unsupported directory %s", path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.HasPrefix(data, []byte("PK\x03\x04")) {
+		return inspectAAR(path, data)
+	}
+	r, err := InspectBytes(path, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect %s: %w", path, err)
+	}
+	return r, nil
+}
+
+func InspectBytes(name string, data []byte) (*Report, error) {
+	r := &Report{Name: name, Format: DetectFormatFromBytes(data)}
+	switch r.Format {
+	case FormatMachO:
+		f, err := macho.NewFile(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		inspectMachO(f, r)
+	case FormatFat:
+		slices, err := ParseFat(data)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range slices {
+			arch := machoArchName(s.Cpu)
+			child, err := InspectBytes(arch, s.Data)
+			if err != nil {
+				return nil, fmt.Errorf("%s slice: %w", arch, err)
+			}
+			// Archive slices (fat static libs) aggregate archs of their members.
+			if child.Arch == "" {
+				child.Arch = arch
+			}
+			r.addArch(child.Arch)
+			r.Children = append(r.Children, child)
+		}
+	case FormatELF:
+		f, err := elf.NewFile(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		inspectELF(f, r)
+	case FormatArchive:
+		if err := inspectArchive(data, r); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported file format")
+	}
+	return r, nil
+}
+
+// Aggregates members of an archive into a single report.
+func inspectArchive(data []byte, r *Report) error {
+	members, err := ParseArchive(data)
+	if err != nil {
+		return err
+	}
+	r.Type = "static"
+	for _, m := range members {
+		if DetectFormatFromBytes(m.Data) == FormatUnknown {
+			continue
+		}
+		mr, err := InspectBytes(m.Name, m.Data)
+		if err != nil {
+			return fmt.Errorf("member %s: %w", m.Name, err)
+		}
+		r.Members++
+		r.addArch(mr.Arch)
+		if r.Platform == "" {
+			r.Platform = mr.Platform
+			r.MinOS = mr.MinOS
+			r.SDKVersion = mr.SDKVersion
+		}
+		for _, sec := range mr.Sections {
+			r.addSection(sec.Name, sec.Size)
+		}
+	}
+	symbols, err := ReadExportedSymbolsFromBytes(data)
+	if err != nil {
+		return err
+	}
+	r.ExportedSymbols = len(symbols)
+	return nil
+}
+
+func inspectXCFramework(path string) (*Report, error) {
+	plistData, err := os.ReadFile(filepath.Join(path, "Info.plist"))
+	if err != nil {
+		return nil, err
+	}
+	plist, err := ParsePlist(plistData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Info.plist of %s: %w", path, err)
+	}
+	root, _ := plist.(map[string]any)
+	libs, _ := root["AvailableLibraries"].([]any)
+
+	r := &Report{Name: path, Format: FormatXCFramework}
+	for _, lib := range libs {
+		libDict, _ := lib.(map[string]any)
+		id, _ := libDict["LibraryIdentifier"].(string)
+		libPath, _ := libDict["LibraryPath"].(string)
+		binPath, _ := libDict["BinaryPath"].(string)
+		if binPath == "" {
+			binPath = libPath
+			// Older Xcode versions don't write `BinaryPath` for frameworks.
+			if strings.HasSuffix(libPath, ".framework") {
+				binPath = filepath.Join(libPath, strings.TrimSuffix(libPath, ".framework"))
+			}
+		}
+
+		child, err := Inspect(filepath.Join(path, id, binPath))
+		if err != nil {
+			return nil, err
+		}
+		child.Name = id
+		platform, _ := libDict["SupportedPlatform"].(string)
+		if variant, _ := libDict["SupportedPlatformVariant"].(string); variant != "" {
+			platform += "-" + variant
+		}
+		if child.Platform == "" {
+			child.Platform = platform
+		}
+		if child.Arch != "" {
+			r.addArch(child.Arch)
+		}
+		for _, arch := range child.Archs {
+			r.addArch(arch)
+		}
+		r.Children = append(r.Children, child)
+	}
+	sort.Slice(r.Children, func(i, j int) bool { return r.Children[i].Name < r.Children[j].Name })
+	return r, nil
+}
+
+// Inspects native libs (jni/<abi>/*.so, *.a) in an AAR.
+func inspectAAR(path string, data []byte) (*Report, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+	r := &Report{Name: path, Format: FormatAAR}
+	for _, zf := range zr.File {
+		if !strings.HasPrefix(zf.Name, "jni/") || (!strings.HasSuffix(zf.Name, ".so") && !strings.HasSuffix(zf.Name, ".a")) {
+			continue
+		}
+		rc, err := zf.Open()
+		if err != nil {
+			return nil, err
+		}
+		entryData, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+		child, err := InspectBytes(zf.Name, entryData)
+		if err != nil {
+			return nil, fmt.Errorf("failed to inspect %s: %w", zf.Name, err)
+		}
+		r.addArch(child.Arch)
+		r.Children = append(r.Children, child)
+	}
+	return r, nil
+}
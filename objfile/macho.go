@@ -0,0 +1,138 @@
+package objfile
+
+import (
+	"debug/macho"
+	"fmt"
+)
+
+// Mach-O load commands not exposed by `debug/macho`.
+const (
+	machoLoadCmdIDDylib         macho.LoadCmd = 0xd
+	machoLoadCmdLoadDylib       macho.LoadCmd = 0xc
+	machoLoadCmdCodeSignature   macho.LoadCmd = 0x1d
+	machoLoadCmdLazyLoadDylib   macho.LoadCmd = 0x20
+	machoLoadCmdVersionMinMacos macho.LoadCmd = 0x24
+	machoLoadCmdVersionMinIos   macho.LoadCmd = 0x25
+	machoLoadCmdBuildVersion    macho.LoadCmd = 0x32
+	machoLoadCmdLoadWeakDylib   macho.LoadCmd = 0x80000018
+	machoLoadCmdRpath           macho.LoadCmd = 0x8000001c
+	machoLoadCmdReexportDylib   macho.LoadCmd = 0x8000001f
+	machoLoadCmdLoadUpwardDylib macho.LoadCmd = 0x80000023
+)
+
+// Values of `platform` in LC_BUILD_VERSION.
+var machoPlatformNames = map[uint32]string{
+	1:  "macos",
+	2:  "ios",
+	3:  "tvos",
+	4:  "watchos",
+	5:  "bridgeos",
+	6:  "maccatalyst",
+	7:  "ios-simulator",
+	8:  "tvos-simulator",
+	9:  "watchos-simulator",
+	10: "driverkit",
+	11: "visionos",
+	12: "visionos-simulator",
+}
+
+func machoArchName(cpu macho.Cpu) string {
+	switch cpu {
+	case macho.CpuArm64:
+		return "arm64"
+	case macho.CpuAmd64:
+		return "x86_64"
+	case macho.CpuArm:
+		return "arm"
+	case macho.Cpu386:
+		return "i386"
+	}
+	return cpu.String()
+}
+
+func machoTypeName(t macho.Type) string {
+	switch t {
+	case macho.TypeObj:
+		return "object"
+	case macho.TypeExec:
+		return "executable"
+	case macho.TypeDylib:
+		return "dylib"
+	case macho.TypeBundle:
+		return "bundle"
+	}
+	return fmt.Sprintf("type 0x%x", uint32(t))
+}
+
+// Version is encoded as xxxx.yy.zz.
+func machoVersionString(v uint32) string {
+	s := fmt.Sprintf("%d.%d", v>>16, (v>>8)&0xff)
+	if patch := v & 0xff; patch != 0 {
+		s += fmt.Sprintf(".%d", patch)
+	}
+	return s
+}
+
+// Reads an `lc_str` (offset from the start of the load command) at `offsetPos`.
+func machoLoadString(f *macho.File, raw []byte, offsetPos int) string {
+	if len(raw) < offsetPos+4 {
+		return ""
+	}
+	offset := int(f.ByteOrder.Uint32(raw[offsetPos : offsetPos+4]))
+	if offset >= len(raw) {
+		return ""
+	}
+	end := offset
+	for end < len(raw) && raw[end] != 0 {
+		end++
+	}
+	return string(raw[offset:end])
+}
+
+func inspectMachO(f *macho.File, r *Report) {
+	r.Arch = machoArchName(f.Cpu)
+	r.Type = machoTypeName(f.Type)
+	for _, load := range f.Loads {
+		raw := load.Raw()
+		if len(raw) < 8 {
+			continue
+		}
+		cmd := macho.LoadCmd(f.ByteOrder.Uint32(raw[0:4]))
+		switch cmd {
+		case machoLoadCmdBuildVersion:
+			if len(raw) >= 20 {
+				platform := f.ByteOrder.Uint32(raw[8:12])
+				r.Platform = machoPlatformNames[platform]
+				if r.Platform == "" {
+					r.Platform = fmt.Sprintf("platform %d", platform)
+				}
+				r.MinOS = machoVersionString(f.ByteOrder.Uint32(raw[12:16]))
+				r.SDKVersion = machoVersionString(f.ByteOrder.Uint32(raw[16:20]))
+			}
+		case machoLoadCmdVersionMinMacos, machoLoadCmdVersionMinIos:
+			if len(raw) >= 16 {
+				r.Platform = "macos"
+				if cmd == machoLoadCmdVersionMinIos {
+					r.Platform = "ios"
+				}
+				r.MinOS = machoVersionString(f.ByteOrder.Uint32(raw[8:12]))
+				r.SDKVersion = machoVersionString(f.ByteOrder.Uint32(raw[12:16]))
+			}
+		case machoLoadCmdIDDylib:
+			r.InstallName = machoLoadString(f, raw, 8)
+		case machoLoadCmdLoadDylib, machoLoadCmdLoadWeakDylib, machoLoadCmdReexportDylib, machoLoadCmdLazyLoadDylib, machoLoadCmdLoadUpwardDylib:
+			r.Dependencies = append(r.Dependencies, machoLoadString(f, raw, 8))
+		case machoLoadCmdRpath:
+			r.Rpaths = append(r.Rpaths, machoLoadString(f, raw, 8))
+		case machoLoadCmdCodeSignature:
+			r.CodeSigned = true
+		}
+	}
+
+	for _, sec := range f.Sections {
+		r.addSection(sec.Seg+","+sec.Name, sec.Size)
+	}
+	set := make(map[string]bool)
+	collectMachOExportedSymbols(f, set)
+	r.ExportedSymbols = len(set)
+}
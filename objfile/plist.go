@@ -0,0 +1,113 @@
+package objfile
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Parses an XML property list into Go values:
+// dict -> map[string]any, array -> []any, string/data/date -> string, integer -> int64, real -> float64,
+// true/false -> bool.
+func ParsePlist(data []byte) (any, error) {
+	dec := xml.NewDecoder(strings.NewReader(string(data)))
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		if start, ok := tok.(xml.StartElement); ok && start.Name.Local != "plist" {
+			return parsePlistValue(dec, start)
+		}
+	}
+}
+
+func parsePlistValue(dec *xml.Decoder, start xml.StartElement) (any, error) {
+	switch start.Name.Local {
+	case "dict":
+		dict := make(map[string]any)
+		var key string
+		for {
+			tok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			switch t := tok.(type) {
+			case xml.StartElement:
+				if t.Name.Local == "key" {
+					if key, err = readPlistText(dec); err != nil {
+						return nil, err
+					}
+					continue
+				}
+				value, err := parsePlistValue(dec, t)
+				if err != nil {
+					return nil, err
+				}
+				dict[key] = value
+			case xml.EndElement:
+				return dict, nil
+			}
+		}
+	case "array":
+		var array []any
+		for {
+			tok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			switch t := tok.(type) {
+			case xml.StartElement:
+				value, err := parsePlistValue(dec, t)
+				if err != nil {
+					return nil, err
+				}
+				array = append(array, value)
+			case xml.EndElement:
+				return array, nil
+			}
+		}
+	case "true", "false":
+		if err := dec.Skip(); err != nil {
+			return nil, err
+		}
+		return start.Name.Local == "true", nil
+	case "integer":
+		text, err := readPlistText(dec)
+		if err != nil {
+			return nil, err
+		}
+		return strconv.ParseInt(text, 10, 64)
+	case "real":
+		text, err := readPlistText(dec)
+		if err != nil {
+			return nil, err
+		}
+		return strconv.ParseFloat(text, 64)
+	case "string", "data", "date":
+		return readPlistText(dec)
+	}
+	return nil, fmt.Errorf("unsupported plist element <%s>", start.Name.Local)
+}
+
+// Reads the text content of the current element, consuming its end element.
+func readPlistText(dec *xml.Decoder) (string, error) {
+	var sb strings.Builder
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return "", io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return "", err
+		}
+		switch t := tok.(type) {
+		case xml.CharData:
+			sb.Write(t)
+		case xml.EndElement:
+			return strings.TrimSpace(sb.String()), nil
+		}
+	}
+}
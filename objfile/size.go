@@ -0,0 +1,307 @@
+package objfile
+
+import (
+	"bytes"
+	"debug/elf"
+	"debug/macho"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Mach-O section types that don't take file space.
+var machoZerofillSectionTypes = map[uint32]bool{
+	0x1:  true, // S_ZEROFILL
+	0xc:  true, // S_GB_ZEROFILL
+	0x12: true, // S_THREAD_LOCAL_ZEROFILL
+}
+
+// Mach-O section attribute of debug sections (e.g. `__DWARF,__debug_info` in object files).
+const machoSectionAttrDebug = 0x02000000
+
+type SizeEntry struct {
+	Name string `json:"name"`
+	Size uint64 `json:"size"`
+}
+
+// Size breakdown of a binary. Only file-backed sections loaded at runtime are counted
+// (e.g. `.bss`, `.symtab` and debug sections are excluded).
+// Entries are sorted by size in descending order.
+type SizeReport struct {
+	Name  string `json:"name"`
+	Total uint64 `json:"total"`
+	// Archive members. Single files have one entry of the file itself. Fat files have one entry per arch.
+	Objects  []*SizeEntry `json:"objects"`
+	Sections []*SizeEntry `json:"sections"`
+	Symbols  []*SizeEntry `json:"symbols"`
+}
+
+type sizeAccumulator struct {
+	total    uint64
+	sections map[string]uint64
+	symbols  map[string]uint64
+}
+
+func newSizeAccumulator() *sizeAccumulator {
+	return &sizeAccumulator{
+		sections: make(map[string]uint64),
+		symbols:  make(map[string]uint64),
+	}
+}
+
+func ComputeSize(path string) (*SizeReport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	acc := newSizeAccumulator()
+	objects := make(map[string]uint64)
+	switch DetectFormatFromBytes(data) {
+	case FormatArchive:
+		members, err := ParseArchive(data)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range members {
+			if DetectFormatFromBytes(m.Data) == FormatUnknown {
+				continue
+			}
+			before := acc.total
+			if err := acc.add(m.Data); err != nil {
+				return nil, fmt.Errorf("member %s: %w", m.Name, err)
+			}
+			objects[m.Name] += acc.total - before
+		}
+	case FormatFat:
+		slices, err := ParseFat(data)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range slices {
+			arch := machoArchName(s.Cpu)
+			before := acc.total
+			if err := acc.add(s.Data); err != nil {
+				return nil, fmt.Errorf("%s slice: %w", arch, err)
+			}
+			objects[arch] += acc.total - before
+		}
+	default:
+		if err := acc.add(data); err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		objects[path] = acc.total
+	}
+
+	return &SizeReport{
+		Name:     path,
+		Total:    acc.total,
+		Objects:  sortedSizeEntries(objects),
+		Sections: sortedSizeEntries(acc.sections),
+		Symbols:  sortedSizeEntries(acc.symbols),
+	}, nil
+}
+
+// Reads a size report from a JSON manifest (output of `kuu size -json`) or computes it from a binary.
+func ReadSizeReport(path string) (*SizeReport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		var report SizeReport
+		if err := json.Unmarshal(data, &report); err != nil {
+			return nil, fmt.Errorf("failed to parse size manifest %s: %w", path, err)
+		}
+		return &report, nil
+	}
+	return ComputeSize(path)
+}
+
+func (acc *sizeAccumulator) add(data []byte) error {
+	switch DetectFormatFromBytes(data) {
+	case FormatArchive:
+		members, err := ParseArchive(data)
+		if err != nil {
+			return err
+		}
+		for _, m := range members {
+			if DetectFormatFromBytes(m.Data) == FormatUnknown {
+				continue
+			}
+			if err := acc.add(m.Data); err != nil {
+				return fmt.Errorf("member %s: %w", m.Name, err)
+			}
+		}
+		return nil
+	case FormatMachO:
+		f, err := macho.NewFile(bytes.NewReader(data))
+		if err != nil {
+			return err
+		}
+		acc.addMachO(f)
+		return nil
+	case FormatELF:
+		f, err := elf.NewFile(bytes.NewReader(data))
+		if err != nil {
+			return err
+		}
+		acc.addELF(f)
+		return nil
+	}
+	return fmt.Errorf("unsupported file format")
+}
+
+// Mach-O symbols don't have sizes, a symbol's size is the distance to the next symbol (or the section end).
+func (acc *sizeAccumulator) addMachO(f *macho.File) {
+	for _, sec := range f.Sections {
+		if machoZerofillSectionTypes[sec.Flags&0xff] || sec.Flags&machoSectionAttrDebug != 0 {
+			continue
+		}
+		acc.total += sec.Size
+		acc.sections[sec.Seg+","+sec.Name] += sec.Size
+	}
+	if f.Symtab == nil {
+		return
+	}
+
+	// K: section index (1-based).
+	sectionSyms := make(map[uint8][]macho.Symbol)
+	for _, sym := range f.Symtab.Syms {
+		if sym.Type&machoNStab != 0 || sym.Sect == 0 || int(sym.Sect) > len(f.Sections) {
+			continue
+		}
+		sectionSyms[sym.Sect] = append(sectionSyms[sym.Sect], sym)
+	}
+	for sectIdx, syms := range sectionSyms {
+		sec := f.Sections[sectIdx-1]
+		if machoZerofillSectionTypes[sec.Flags&0xff] || sec.Flags&machoSectionAttrDebug != 0 {
+			continue
+		}
+		sort.Slice(syms, func(i, j int) bool { return syms[i].Value < syms[j].Value })
+		end := sec.Addr + sec.Size
+		for i, sym := range syms {
+			next := end
+			if i+1 < len(syms) {
+				next = syms[i+1].Value
+			}
+			if next > sym.Value && sym.Value >= sec.Addr {
+				acc.symbols[strings.TrimPrefix(sym.Name, "_")] += next - sym.Value
+			}
+		}
+	}
+}
+
+func (acc *sizeAccumulator) addELF(f *elf.File) {
+	// Like Mach-O sections, only sections loaded at runtime are counted (e.g. `.symtab` and `.debug_*` are excluded).
+	for _, sec := range f.Sections {
+		if sec.Flags&elf.SHF_ALLOC == 0 || sec.Type == elf.SHT_NOBITS {
+			continue
+		}
+		acc.total += sec.Size
+		acc.sections[sec.Name] += sec.Size
+	}
+	syms, err := f.Symbols()
+	if err != nil {
+		// Stripped shared libs only have dynamic symbols.
+		syms, _ = f.DynamicSymbols()
+	}
+	for _, sym := range syms {
+		if sym.Size == 0 || sym.Section == elf.SHN_UNDEF || sym.Section >= elf.SHN_LORESERVE {
+			continue
+		}
+		if int(sym.Section) < len(f.Sections) {
+			if sec := f.Sections[sym.Section]; sec.Flags&elf.SHF_ALLOC == 0 || sec.Type == elf.SHT_NOBITS {
+				continue
+			}
+		}
+		symType := elf.ST_TYPE(sym.Info)
+		if symType != elf.STT_FUNC && symType != elf.STT_OBJECT {
+			continue
+		}
+		acc.symbols[sym.Name] += sym.Size
+	}
+}
+
+func sortedSizeEntries(m map[string]uint64) []*SizeEntry {
+	entries := make([]*SizeEntry, 0, len(m))
+	for name, size := range m {
+		entries = append(entries, &SizeEntry{Name: name, Size: size})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Size != entries[j].Size {
+			return entries[i].Size > entries[j].Size
+		}
+		return entries[i].Name < entries[j].Name
+	})
+	return entries
+}
+
+type SizeDiffEntry struct {
+	Name  string `json:"name"`
+	Old   uint64 `json:"old"`
+	New   uint64 `json:"new"`
+	Delta int64  `json:"delta"`
+}
+
+// Entries are sorted by delta in descending order (largest growth first).
+// Unchanged entries are omitted.
+type SizeDiff struct {
+	Total    *SizeDiffEntry   `json:"total"`
+	Objects  []*SizeDiffEntry `json:"objects"`
+	Sections []*SizeDiffEntry `json:"sections"`
+	Symbols  []*SizeDiffEntry `json:"symbols"`
+}
+
+func DiffSize(baseline, current *SizeReport) *SizeDiff {
+	return &SizeDiff{
+		Total: &SizeDiffEntry{
+			Name:  current.Name,
+			Old:   baseline.Total,
+			New:   current.Total,
+			Delta: int64(current.Total) - int64(baseline.Total),
+		},
+		// Single files are named by their paths, which differ between the baseline and the current build.
+		Objects:  diffSizeEntries(baseline.Objects, current.Objects, len(baseline.Objects) == 1 && len(current.Objects) == 1),
+		Sections: diffSizeEntries(baseline.Sections, current.Sections, false),
+		Symbols:  diffSizeEntries(baseline.Symbols, current.Symbols, false),
+	}
+}
+
+func diffSizeEntries(baseline, current []*SizeEntry, matchByIndex bool) []*SizeDiffEntry {
+	if matchByIndex {
+		entry := &SizeDiffEntry{Name: current[0].Name, Old: baseline[0].Size, New: current[0].Size}
+		entry.Delta = int64(entry.New) - int64(entry.Old)
+		if entry.Delta == 0 {
+			return nil
+		}
+		return []*SizeDiffEntry{entry}
+	}
+
+	entries := make(map[string]*SizeDiffEntry)
+	for _, e := range baseline {
+		entries[e.Name] = &SizeDiffEntry{Name: e.Name, Old: e.Size}
+	}
+	for _, e := range current {
+		if entry, ok := entries[e.Name]; ok {
+			entry.New = e.Size
+		} else {
+			entries[e.Name] = &SizeDiffEntry{Name: e.Name, New: e.Size}
+		}
+	}
+	var result []*SizeDiffEntry
+	for _, entry := range entries {
+		entry.Delta = int64(entry.New) - int64(entry.Old)
+		if entry.Delta != 0 {
+			result = append(result, entry)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Delta != result[j].Delta {
+			return result[i].Delta > result[j].Delta
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
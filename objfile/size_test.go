@@ -0,0 +1,92 @@
+package objfile
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestComputeSizeFatArchive(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "libfoo.a")
+	if err := os.WriteFile(path, testFatArchive(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	r, err := ComputeSize(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// arm64: 8 + 4, x86_64: 16 + 4.
+	if r.Total != 32 {
+		t.Fatalf("expected total 32, got %d", r.Total)
+	}
+	want := map[string]uint64{"x86_64": 20, "arm64": 12}
+	if len(r.Objects) != len(want) {
+		t.Fatalf("unexpected objects %v", r.Objects)
+	}
+	for _, e := range r.Objects {
+		if want[e.Name] != e.Size {
+			t.Fatalf("expected %s size %d, got %d", e.Name, want[e.Name], e.Size)
+		}
+	}
+}
+
+func TestComputeSizeELFAllocOnly(t *testing.T) {
+	// The test binary is an ELF file with symbol and debug sections on Linux.
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(exe)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if DetectFormatFromBytes(data) != FormatELF {
+		t.Skip("test binary is not an ELF file")
+	}
+	r, err := ComputeSize(exe)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, sec := range r.Sections {
+		if sec.Name == ".symtab" || sec.Name == ".strtab" || strings.HasPrefix(sec.Name, ".debug_") {
+			t.Fatalf("non-alloc section %s is counted", sec.Name)
+		}
+	}
+}
+
+func TestDiffSize(t *testing.T) {
+	baseline := &SizeReport{
+		Name:     "old/libfoo.so",
+		Total:    100,
+		Objects:  []*SizeEntry{{Name: "old/libfoo.so", Size: 100}},
+		Sections: []*SizeEntry{{Name: ".text", Size: 80}, {Name: ".data", Size: 20}},
+		Symbols:  []*SizeEntry{{Name: "foo", Size: 50}, {Name: "bar", Size: 30}, {Name: "gone", Size: 10}},
+	}
+	current := &SizeReport{
+		Name:     "new/libfoo.so",
+		Total:    130,
+		Objects:  []*SizeEntry{{Name: "new/libfoo.so", Size: 130}},
+		Sections: []*SizeEntry{{Name: ".text", Size: 110}, {Name: ".data", Size: 20}},
+		Symbols:  []*SizeEntry{{Name: "foo", Size: 70}, {Name: "bar", Size: 30}, {Name: "added", Size: 15}},
+	}
+	diff := DiffSize(baseline, current)
+	if d := diff.Total; d.Name != "new/libfoo.so" || d.Old != 100 || d.New != 130 || d.Delta != 30 {
+		t.Fatalf("unexpected total %+v", d)
+	}
+	// Single files are matched regardless of their paths.
+	if len(diff.Objects) != 1 || diff.Objects[0].Delta != 30 {
+		t.Fatalf("unexpected objects %v", diff.Objects)
+	}
+	if len(diff.Sections) != 1 || diff.Sections[0].Name != ".text" || diff.Sections[0].Delta != 30 {
+		t.Fatalf("unexpected sections %v", diff.Sections)
+	}
+	var got []string
+	for _, e := range diff.Symbols {
+		got = append(got, e.Name)
+	}
+	// Sorted by delta, unchanged `bar` is omitted.
+	if want := "foo,added,gone"; strings.Join(got, ",") != want {
+		t.Fatalf("expected symbols %s, got %s", want, strings.Join(got, ","))
+	}
+}
@@ -0,0 +1,144 @@
+package objfile
+
+import (
+	"bytes"
+	"debug/elf"
+	"debug/macho"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Mach-O nlist type bits.
+const (
+	machoNStab = 0xe0
+	machoNType = 0x0e
+	machoNExt  = 0x01
+	machoNUndf = 0x00
+)
+
+// Returns sorted, deduplicated names of defined external symbols of a Mach-O (thin or fat) file, an ELF file
+// or all object files in an ar archive (thin or fat).
+// The leading underscore added by the Mach-O C ABI is removed so names match across platforms
+// (e.g. `_png_read_info` -> `png_read_info`, `__ZN3foo` -> `_ZN3foo`).
+func ReadExportedSymbols(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	symbols, err := ReadExportedSymbolsFromBytes(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read symbols of %s: %w", path, err)
+	}
+	return symbols, nil
+}
+
+func ReadExportedSymbolsFromBytes(data []byte) ([]string, error) {
+	set := make(map[string]bool)
+	if err := collectExportedSymbols(data, set); err != nil {
+		return nil, err
+	}
+	symbols := make([]string, 0, len(set))
+	for name := range set {
+		symbols = append(symbols, name)
+	}
+	sort.Strings(symbols)
+	return symbols, nil
+}
+
+func collectExportedSymbols(data []byte, set map[string]bool) error {
+	switch DetectFormatFromBytes(data) {
+	case FormatArchive:
+		members, err := ParseArchive(data)
+		if err != nil {
+			return err
+		}
+		for _, m := range members {
+			// Skip non-object members (e.g. LLVM bitcode or text files).
+			if DetectFormatFromBytes(m.Data) == FormatUnknown {
+				continue
+			}
+			if err := collectExportedSymbols(m.Data, set); err != nil {
+				return fmt.Errorf("member %s: %w", m.Name, err)
+			}
+		}
+		return nil
+	case FormatMachO:
+		f, err := macho.NewFile(bytes.NewReader(data))
+		if err != nil {
+			return err
+		}
+		collectMachOExportedSymbols(f, set)
+		return nil
+	case FormatFat:
+		slices, err := ParseFat(data)
+		if err != nil {
+			return err
+		}
+		for _, s := range slices {
+			if err := collectExportedSymbols(s.Data, set); err != nil {
+				return fmt.Errorf("%s slice: %w", machoArchName(s.Cpu), err)
+			}
+		}
+		return nil
+	case FormatELF:
+		f, err := elf.NewFile(bytes.NewReader(data))
+		if err != nil {
+			return err
+		}
+		return collectELFExportedSymbols(f, set)
+	}
+	return fmt.Errorf("unsupported file format")
+}
+
+func collectMachOExportedSymbols(f *macho.File, set map[string]bool) {
+	if f.Symtab == nil {
+		return
+	}
+	for _, sym := range f.Symtab.Syms {
+		if sym.Type&machoNStab != 0 || sym.Type&machoNExt == 0 || sym.Type&machoNType == machoNUndf {
+			continue
+		}
+		set[strings.TrimPrefix(sym.Name, "_")] = true
+	}
+}
+
+func collectELFExportedSymbols(f *elf.File, set map[string]bool) error {
+	var syms []elf.Symbol
+	var err error
+	// Shared libs export dynamic symbols, object files (in archives) use the regular symbol table.
+	if f.Type == elf.ET_DYN {
+		syms, err = f.DynamicSymbols()
+	} else {
+		syms, err = f.Symbols()
+	}
+	if err != nil {
+		if errors.Is(err, elf.ErrNoSymbols) {
+			return nil
+		}
+		return err
+	}
+	for _, sym := range syms {
+		bind := elf.ST_BIND(sym.Info)
+		if bind != elf.STB_GLOBAL && bind != elf.STB_WEAK {
+			continue
+		}
+		if sym.Section == elf.SHN_UNDEF {
+			continue
+		}
+		if f.Type == elf.ET_DYN {
+			// Version definitions (e.g. `ZLIB_1.2.0`) are absolute symbols.
+			if sym.Section == elf.SHN_ABS {
+				continue
+			}
+			vis := elf.ST_VISIBILITY(sym.Other)
+			if vis != elf.STV_DEFAULT && vis != elf.STV_PROTECTED {
+				continue
+			}
+		}
+		set[sym.Name] = true
+	}
+	return nil
+}
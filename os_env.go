@@ -1,9 +1,12 @@
 package ku
 
 import (
+	"bufio"
+	"debug/elf"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/mgenware/j9/v3"
@@ -131,18 +134,7 @@ func (e *OSEnv) fetchLDPath() string {
 
 func (e *OSEnv) GetAndroidSDKPath() string {
 	if e.IsAndroidPlatform() {
-		return globalCachedString("android_sdk", func() string {
-			path := os.Getenv("ANDROID_SDK_PATH")
-			if path != "" {
-				return path
-			}
-			usr, err := os.UserHomeDir()
-			if err != nil {
-				panic(err)
-			}
-			path = filepath.Join(usr, "Library/Android/sdk")
-			return path
-		})
+		return globalCachedString("android_sdk", ResolveAndroidSDKPath)
 	}
 	e.ThrowUnsupportedError()
 	panic("unreachable")
@@ -151,15 +143,7 @@ func (e *OSEnv) GetAndroidSDKPath() string {
 func (e *OSEnv) GetNDKPath() string {
 	if e.IsAndroidPlatform() {
 		return globalCachedString("ndk-path", func() string {
-			path := os.Getenv("ANDROID_NDK_PATH")
-			if path != "" {
-				return path
-			}
-			path = e.shell.Args.NDK
-			// If `NDKInput` is not an absolute path, it's considered an NDK version.
-			if !strings.HasPrefix(path, "/") {
-				path = filepath.Join(e.GetAndroidSDKPath(), "ndk", path)
-			}
+			path := ResolveNDKPath(e.shell.Args.NDK)
 			io2.DirectoryMustExist(path)
 			return path
 		})
@@ -168,6 +152,75 @@ func (e *OSEnv) GetNDKPath() string {
 	panic("unreachable")
 }
 
+// Returns $ANDROID_SDK_PATH or the default Android SDK path.
+func ResolveAndroidSDKPath() string {
+	path := os.Getenv("ANDROID_SDK_PATH")
+	if path != "" {
+		return path
+	}
+	usr, err := os.UserHomeDir()
+	if err != nil {
+		panic(err)
+	}
+	return filepath.Join(usr, "Library/Android/sdk")
+}
+
+// Returns $ANDROID_NDK_PATH if set. Otherwise, `ndk` is used as an absolute NDK path or an NDK version
+// under the Android SDK. The path is not checked for existence.
+func ResolveNDKPath(ndk string) string {
+	path := os.Getenv("ANDROID_NDK_PATH")
+	if path != "" {
+		return path
+	}
+	path = ndk
+	// If `NDKInput` is not an absolute path, it's considered an NDK version.
+	if !strings.HasPrefix(path, "/") {
+		path = filepath.Join(ResolveAndroidSDKPath(), "ndk", path)
+	}
+	return path
+}
+
+// Returns `Pkg.Revision` (e.g. `27.0.12077973`) in source.properties of an NDK, or an empty string if not found.
+func ReadNDKRevision(ndkPath string) string {
+	f, err := os.Open(filepath.Join(ndkPath, "source.properties"))
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), "=")
+		if ok && strings.TrimSpace(key) == "Pkg.Revision" {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+// Returns the major version of the NDK, or 0 if unknown.
+func (e *OSEnv) GetNDKMajorVersion() int {
+	rev := globalCachedString("ndk-revision", func() string {
+		return ReadNDKRevision(e.GetNDKPath())
+	})
+	major, _, _ := strings.Cut(rev, ".")
+	ver, _ := strconv.Atoi(major)
+	return ver
+}
+
+// Returns the LLVM toolchain root of an NDK. The path is not checked for existence.
+func GetNDKToolchainRootPath(ndkPath string) string {
+	return filepath.Join(ndkPath, "toolchains", "llvm", "prebuilt", NDKHostTag)
+}
+
+// Returns the name of the NDK clang binary for an arch and API level.
+func GetNDKClangName(arch ArchEnum, cpp bool) string {
+	binName := GetOldArch(arch) + "-linux-android" + MinAndroidAPI + "-clang"
+	if cpp {
+		binName += "++"
+	}
+	return binName
+}
+
 func (e *OSEnv) GetNDKCmakeToolchainFile() string {
 	if e.IsAndroidPlatform() {
 		return globalCachedString("ndk_cmake_toolchain", func() string {
@@ -197,8 +250,7 @@ func (e *OSEnv) LibTypeExt(libType LibType) string {
 func (e *OSEnv) getNDKToolchainRootPath() string {
 	ndkPath := e.GetNDKPath()
 	return globalCachedString("ndk-toolchain-root", func() string {
-		path := filepath.Join(ndkPath, "toolchains", "llvm", "prebuilt", "darwin-x86_64")
-		return io2.DirectoryMustExist(path)
+		return io2.DirectoryMustExist(GetNDKToolchainRootPath(ndkPath))
 	})
 }
 
@@ -209,6 +261,18 @@ func (e *OSEnv) GetNDKToolchainBinPath(name string) string {
 	})
 }
 
+// Xcode doesn't ship llvm-objcopy, an LLVM installation (e.g. Homebrew) in $PATH is required on Darwin.
+func (e *OSEnv) GetLLVMObjcopyPath() string {
+	if e.IsAndroidPlatform() {
+		return e.GetNDKToolchainBinPath("llvm-objcopy")
+	}
+	if e.IsDarwinPlatform() {
+		return e.GetWhichExe("llvm-objcopy")
+	}
+	e.ThrowUnsupportedError()
+	panic("unreachable")
+}
+
 func (e *OSEnv) StripFile(src, dst string) {
 	var stripBin string
 	var args []string
@@ -226,11 +290,7 @@ func (e *OSEnv) StripFile(src, dst string) {
 }
 
 func (e *OSEnv) getNDKClangPath(cpp bool) string {
-	binName := GetOldArch(e.Arch) + "-linux-android" + MinAndroidAPI + "-clang"
-	if cpp {
-		binName += "++"
-	}
-	return e.GetNDKToolchainBinPath(binName)
+	return e.GetNDKToolchainBinPath(GetNDKClangName(e.Arch, cpp))
 }
 
 //go:noreturn
@@ -294,6 +354,41 @@ func (e *OSEnv) VerifyFileArch(libType LibType, file string) {
 	}
 }
 
+// Checks that every PT_LOAD segment of an ELF shared lib is aligned to at least `pageSize`.
+func (e *OSEnv) VerifyAndroidLibPageAlignment(file string, pageSize uint64) {
+	logger := e.shell.Logger()
+
+	logger.Log(j9.LogLevelVerbose, "🔍 Verifying page alignment for file "+file)
+	misaligned, err := getMisalignedLoadSegments(file, pageSize)
+	if err != nil {
+		e.shell.Quit(fmt.Sprintf("Error reading ELF file %s: %v", file, err))
+	}
+	if len(misaligned) > 0 {
+		e.shell.Quit(fmt.Sprintf("PT_LOAD segments not aligned to %d bytes for file %s: %s", pageSize, file, strings.Join(misaligned, ", ")))
+	}
+	logger.Log(j9.LogLevelSuccess, fmt.Sprintf("✅ Page alignment verified for file %s, expected: %d", file, pageSize))
+}
+
+// Returns descriptions of PT_LOAD segments aligned to less than `pageSize`.
+func getMisalignedLoadSegments(file string, pageSize uint64) ([]string, error) {
+	f, err := elf.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var misaligned []string
+	for i, prog := range f.Progs {
+		if prog.Type != elf.PT_LOAD {
+			continue
+		}
+		if prog.Align < pageSize {
+			misaligned = append(misaligned, fmt.Sprintf("segment %d: align 0x%x", i, prog.Align))
+		}
+	}
+	return misaligned, nil
+}
+
 func (e *OSEnv) GetDarwinClangTargetTriple() string {
 	if !e.IsDarwinPlatform() {
 		e.ThrowUnsupportedError()
@@ -0,0 +1,96 @@
+package ku
+
+import (
+	"bytes"
+	"debug/elf"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// Writes an ELF64 shared lib with program headers only.
+func writeTestELF(t *testing.T, progs []elf.Prog64) string {
+	t.Helper()
+	const ehsize, phentsize = 64, 56
+	hdr := elf.Header64{
+		Type:      uint16(elf.ET_DYN),
+		Machine:   uint16(elf.EM_AARCH64),
+		Version:   uint32(elf.EV_CURRENT),
+		Phoff:     ehsize,
+		Ehsize:    ehsize,
+		Phentsize: phentsize,
+		Phnum:     uint16(len(progs)),
+	}
+	copy(hdr.Ident[:], elf.ELFMAG)
+	hdr.Ident[elf.EI_CLASS] = byte(elf.ELFCLASS64)
+	hdr.Ident[elf.EI_DATA] = byte(elf.ELFDATA2LSB)
+	hdr.Ident[elf.EI_VERSION] = byte(elf.EV_CURRENT)
+
+	var buf bytes.Buffer
+	if err := binary.Write(&buf, binary.LittleEndian, hdr); err != nil {
+		t.Fatal(err)
+	}
+	if err := binary.Write(&buf, binary.LittleEndian, progs); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "libfoo.so")
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestGetMisalignedLoadSegments(t *testing.T) {
+	file := writeTestELF(t, []elf.Prog64{
+		{Type: uint32(elf.PT_PHDR), Align: 8},
+		{Type: uint32(elf.PT_LOAD), Align: 0x4000},
+		{Type: uint32(elf.PT_LOAD), Align: 0x1000},
+		{Type: uint32(elf.PT_GNU_STACK), Align: 0},
+		{Type: uint32(elf.PT_LOAD), Align: 0x10000},
+	})
+
+	got, err := getMisalignedLoadSegments(file, 0x4000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"segment 2: align 0x1000"}; !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	got, err = getMisalignedLoadSegments(file, 0x1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 0 {
+		t.Errorf("4 KB: got %q", got)
+	}
+
+	if _, err := getMisalignedLoadSegments(filepath.Join(t.TempDir(), "missing.so"), 0x4000); err == nil {
+		t.Error("missing file: no error")
+	}
+}
+
+func TestVerifyAndroidLibPageAlignment(t *testing.T) {
+	file := writeTestELF(t, []elf.Prog64{
+		{Type: uint32(elf.PT_LOAD), Align: 0x4000},
+		{Type: uint32(elf.PT_LOAD), Align: 0x4000},
+	})
+	e := NewOSEnv(NewShell(CreateDefaultTunnel(), &CLIArgs{}), SDKAndroid, ArchArm64)
+	// Quits on misaligned segments.
+	e.VerifyAndroidLibPageAlignment(file, DefaultAndroidMaxPageSize)
+}
+
+func TestReadNDKRevision(t *testing.T) {
+	dir := t.TempDir()
+	if got := ReadNDKRevision(dir); got != "" {
+		t.Errorf("missing file: got %q", got)
+	}
+	writeTestFiles(t, dir, map[string]string{
+		"source.properties": "Pkg.Desc = Android NDK\nPkg.Revision = 27.0.12077973\n",
+	})
+	if got := ReadNDKRevision(dir); got != "27.0.12077973" {
+		t.Errorf("got %q", got)
+	}
+}
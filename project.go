@@ -1,11 +1,9 @@
 package ku
 
 import (
-	"fmt"
 	"path/filepath"
 
 	"github.com/mgenware/j9/v3"
-	"github.com/mgenware/ku-builder/io2"
 )
 
 type ProjectInitOptions struct {
@@ -23,6 +21,39 @@ type ProjectInitOptions struct {
 	// Make options.
 	MakeExtraCAndCXXFlags []string
 	MakeExtraLDFlags      []string
+	// Extra lines of the generated `config.site`, e.g. `ac_cv_func_malloc_0_nonnull=yes`.
+	MakeConfigSite []string
+	// Don't pass `--host`, `--build`, `--prefix`, static/shared and sysroot args to autoconf configure scripts.
+	MakeSkipAutoconfArgs bool
+
+	// Plain make options. `Args` are passed to the build targets.
+	// Subdir of the source dir to run make in (`make -C`), e.g. `lib`.
+	PlainMakeDir string
+	// Extra make variables in `KEY=VALUE` format, e.g. `CFLAGS+=-DFOO`.
+	PlainMakeVars []string
+	// Defaults to the default target.
+	PlainMakeBuildTargets []string
+	// Defaults to `install`, or none if `PlainMakeInstallHeaders` or `PlainMakeInstallLibs` is set.
+	PlainMakeInstallTargets []string
+	// Pass `DESTDIR=<out dir>` and an empty `PREFIX` instead of `PREFIX=<out dir>`.
+	PlainMakeUseDestDir bool
+	// Globs relative to the make dir, copied to `OutIncludeDir` and `OutLibDir` after the install targets.
+	PlainMakeInstallHeaders []string
+	PlainMakeInstallLibs    []string
+
+	// Cargo options. `Args` are passed to `cargo rustc`, e.g. `--features foo`.
+	// Package to build in a workspace.
+	CargoPackage string
+	// Defaults to `dev` for debug builds and `release` otherwise.
+	CargoProfile string
+	// If set, generates this header (e.g. `foo.h`) in `OutIncludeDir` with cbindgen.
+	CargoCbindgenHeader string
+
+	// Go options. `Args` are passed to `go build`, e.g. `-ldflags=-s -w`.
+	// Package to build, defaults to `.`.
+	GoPackage string
+	// Build tags. `GoIosSimulatorTag` is added for the iOS simulator.
+	GoTags []string
 }
 
 type Project interface {
@@ -62,9 +93,22 @@ func (p *CMakeProject) Init(opt *ProjectInitOptions) {
 	b := p.builder
 	b.CloneAndGotoRepoSource()
 
-	args := b.GetCmakeGenArgsWithOptions(opt.GetCmakeSetupArgsOptions)
-	if len(opt.Args) > 0 {
-		args = append(args, opt.Args...)
+	// `-G` in args is passed as `GetCmakeGenArgsOptions.Generator`, which also sets the make program.
+	userArgs := opt.Args
+	genArgsOpt := &GetCmakeGenArgsOptions{}
+	if opt.GetCmakeSetupArgsOptions != nil {
+		*genArgsOpt = *opt.GetCmakeSetupArgsOptions
+	}
+	if generator, rest := extractCmakeGeneratorArg(userArgs); generator != "" {
+		userArgs = rest
+		if genArgsOpt.Generator == "" {
+			genArgsOpt.Generator = generator
+		}
+	}
+
+	args := b.GetCmakeGenArgsWithOptions(genArgsOpt)
+	if len(userArgs) > 0 {
+		args = append(args, userArgs...)
 	}
 
 	env := []string{}
@@ -132,15 +176,23 @@ func (p *MakeProject) Init(opt *ProjectInitOptions) {
 	env = append(env, b.GetKuBuiltinEnv(true)...)
 	env = append(env, opt.Env...)
 
-	// Run ./configure at build dir, not source dir.
-	b.GoToBuildDir()
+	b.bootstrapAutotools(srcDir)
 	configureFilePath := filepath.Join(srcDir, "configure")
-	if !io2.FileExists(configureFilePath) {
-		b.Shell.Quit(fmt.Sprintf("configure script not found at %s", configureFilePath))
+
+	// Hand-written configure scripts (e.g. ffmpeg) don't accept autoconf args.
+	args := opt.Args
+	if isAutoconfScript(configureFilePath) {
+		if !opt.MakeSkipAutoconfArgs {
+			args = append(b.getAutoconfArgs(opt.Args), opt.Args...)
+		}
+		env = append([]string{"CONFIG_SITE=" + b.writeAutoconfConfigSite(opt.MakeConfigSite)}, env...)
 	}
+
+	// Run ./configure at build dir, not source dir.
+	b.GoToBuildDir()
 	b.Shell.Spawn(&j9.SpawnOpt{
 		Name: configureFilePath,
-		Args: opt.Args,
+		Args: args,
 		Env:  env,
 	})
 }
@@ -210,3 +262,218 @@ func (p *MesonProject) Install(outFile string, vfOpt *VerifyFileOptions) {
 	b := p.builder
 	b.RunMesonInstall(outFile, vfOpt)
 }
+
+// Builds Makefiles without configure (e.g. bzip2, lz4). Toolchain, compiler flags and install prefix
+// are passed as make variables. As they build in-source, the source is always isolated per SDK/arch.
+type PlainMakeProject struct {
+	builder *Builder
+	opt     *ProjectInitOptions
+	makeDir string
+	vars    []string
+	env     []string
+}
+
+func (p *PlainMakeProject) CoreBuilder() *Builder {
+	return p.builder
+}
+
+func NewPlainMakeProject(repo *RepoInfo, buildEnv *BuildEnv, libType LibType) Project {
+	isolatedRepo := *repo
+	isolatedRepo.IsolateSource = true
+	builder := NewBuilder(&isolatedRepo, buildEnv, libType)
+	return &PlainMakeProject{
+		builder: builder,
+	}
+}
+
+func (p *PlainMakeProject) Init(opt *ProjectInitOptions) {
+	if opt == nil {
+		opt = &ProjectInitOptions{}
+	}
+
+	b := p.builder
+	srcDir := b.CloneAndGotoRepoSource()
+
+	p.opt = opt
+	p.makeDir = filepath.Join(srcDir, opt.PlainMakeDir)
+	p.vars = b.GetPlainMakeVars(opt.PlainMakeUseDestDir, opt.PlainMakeVars)
+	// Note: `opt.Env` should come at last to allow overriding builtin env if needed.
+	p.env = append(b.GetCoreSetupEnv(), opt.Env...)
+}
+
+func (p *PlainMakeProject) makeArgs(args ...string) []string {
+	return append(append([]string{"-C", p.makeDir}, p.vars...), args...)
+}
+
+func (p *PlainMakeProject) Build() {
+	b := p.builder
+	b.RunMakeWithArgs(&j9.SpawnOpt{
+		Args: p.makeArgs(append(p.opt.Args, p.opt.PlainMakeBuildTargets...)...),
+		Env:  p.env,
+	})
+}
+
+func (p *PlainMakeProject) Install(outFile string, vfOpt *VerifyFileOptions) {
+	b := p.builder
+	be := b.BuildEnv
+	opt := p.opt
+
+	targets := opt.PlainMakeInstallTargets
+	if len(targets) == 0 && len(opt.PlainMakeInstallHeaders) == 0 && len(opt.PlainMakeInstallLibs) == 0 {
+		targets = []string{"install"}
+	}
+	if len(targets) > 0 {
+		b.RunMakeWithArgs(&j9.SpawnOpt{
+			Args: p.makeArgs(targets...),
+			Env:  p.env,
+		})
+	}
+	b.InstallByGlobs(p.makeDir, opt.PlainMakeInstallHeaders, be.OutIncludeDir)
+	b.InstallByGlobs(p.makeDir, opt.PlainMakeInstallLibs, be.OutLibDir)
+	b.runPostInstall(outFile, vfOpt)
+}
+
+// Builds a Rust crate as a `staticlib` or `cdylib` depending on `LibType`, regardless of
+// `crate-type` in Cargo.toml.
+type CargoProject struct {
+	builder  *Builder
+	opt      *ProjectInitOptions
+	manifest string
+	libName  string
+	crateDir string
+}
+
+func (p *CargoProject) CoreBuilder() *Builder {
+	return p.builder
+}
+
+func NewCargoProject(repo *RepoInfo, buildEnv *BuildEnv, libType LibType) Project {
+	builder := NewBuilder(repo, buildEnv, libType)
+	return &CargoProject{
+		builder: builder,
+	}
+}
+
+func (p *CargoProject) Init(opt *ProjectInitOptions) {
+	if opt == nil {
+		opt = &ProjectInitOptions{}
+	}
+
+	b := p.builder
+	srcDir := b.CloneAndGotoRepoSource()
+	b.checkRustTarget()
+
+	p.opt = opt
+	p.manifest = filepath.Join(srcDir, "Cargo.toml")
+	p.libName, p.crateDir = b.getCargoLib(p.manifest, opt.CargoPackage)
+}
+
+func (p *CargoProject) Build() {
+	b := p.builder
+	opt := p.opt
+
+	crateType := "staticlib"
+	if b.LibType == LibTypeDynamic {
+		crateType = "cdylib"
+	}
+	args := []string{
+		"rustc", "--lib",
+		"--crate-type", crateType,
+		"--target", b.OS.GetRustTargetTriple(),
+		"--profile", b.getCargoProfile(opt.CargoProfile),
+		"--manifest-path", p.manifest,
+	}
+	if opt.CargoPackage != "" {
+		args = append(args, "--package", opt.CargoPackage)
+	}
+	args = append(args, opt.Args...)
+
+	// Note: `opt.Env` should come at last to allow overriding builtin env if needed.
+	env := b.GetCargoEnv(p.libName, opt.CargoProfile)
+	env = append(env, b.GetKuBuiltinEnv(false)...)
+	env = append(env, opt.Env...)
+
+	b.GoToBuildDir()
+	b.Shell.Spawn(&j9.SpawnOpt{
+		Name: "cargo",
+		Args: args,
+		Env:  env,
+	})
+}
+
+func (p *CargoProject) Install(outFile string, vfOpt *VerifyFileOptions) {
+	b := p.builder
+	be := b.BuildEnv
+
+	artifact := "lib" + p.libName + b.OS.LibTypeExt(b.LibType)
+	b.InstallByGlobs(b.GetCargoArtifactDir(p.opt.CargoProfile), []string{artifact}, be.OutLibDir)
+	if p.opt.CargoCbindgenHeader != "" {
+		b.RunCbindgen(p.crateDir, p.opt.CargoCbindgenHeader)
+	}
+	b.runPostInstall(outFile, vfOpt)
+}
+
+// Builds a Go package as a `c-archive` or `c-shared` lib depending on `LibType`.
+// The lib is named after the target, e.g. `libfoo.a` and `libfoo.h`.
+type GoProject struct {
+	builder *Builder
+	opt     *ProjectInitOptions
+	srcDir  string
+}
+
+func (p *GoProject) CoreBuilder() *Builder {
+	return p.builder
+}
+
+func NewGoProject(repo *RepoInfo, buildEnv *BuildEnv, libType LibType) Project {
+	builder := NewBuilder(repo, buildEnv, libType)
+	return &GoProject{
+		builder: builder,
+	}
+}
+
+func (p *GoProject) Init(opt *ProjectInitOptions) {
+	if opt == nil {
+		opt = &ProjectInitOptions{}
+	}
+
+	b := p.builder
+	p.opt = opt
+	p.srcDir = b.CloneAndGotoRepoSource()
+}
+
+func (p *GoProject) Build() {
+	b := p.builder
+	opt := p.opt
+
+	pkg := opt.GoPackage
+	if pkg == "" {
+		pkg = "."
+	}
+	args := b.GetGoBuildArgs(b.GetGoLibPath(), opt.GoTags)
+	args = append(args, opt.Args...)
+	args = append(args, pkg)
+
+	// Note: `opt.Env` should come at last to allow overriding builtin env if needed.
+	env := b.GetGoEnv()
+	env = append(env, b.GetKuBuiltinEnv(false)...)
+	env = append(env, opt.Env...)
+
+	// `go build` resolves the package in the module of the working dir. The build dir is created by `GetGoLibPath`.
+	b.Shell.Spawn(&j9.SpawnOpt{
+		Name:       "go",
+		Args:       args,
+		Env:        env,
+		WorkingDir: p.srcDir,
+	})
+}
+
+func (p *GoProject) Install(outFile string, vfOpt *VerifyFileOptions) {
+	b := p.builder
+	be := b.BuildEnv
+
+	libPath := b.GetGoLibPath()
+	b.InstallByGlobs(filepath.Dir(libPath), []string{filepath.Base(libPath)}, be.OutLibDir)
+	b.InstallByGlobs(filepath.Dir(libPath), []string{be.TargetLibName + ".h"}, be.OutIncludeDir)
+	b.runPostInstall(outFile, vfOpt)
+}
@@ -0,0 +1,495 @@
+package ku
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/mgenware/j9/v3"
+	"github.com/mgenware/ku-builder/io2"
+	"github.com/mgenware/ku-builder/util"
+)
+
+// Default recipe file name used by the `ku` command.
+const DefaultRecipeFile = "ku.recipe.json"
+
+// A declarative build recipe. Libraries are built with the existing `Project` types, so a recipe
+// can replace a Go package that only sets up a repo, a build system and its args.
+//
+// String values in args, env and outputs support `${VAR}` interpolation. `KU_*` variables
+// (same as the builtin env, e.g. `${KU_OUT_INCLUDE_DIR}`) are resolved per SDK/arch, others are read from the environment.
+type Recipe struct {
+	// Target built when -target is not specified. Defaults to the last lib.
+	DefaultTarget string `json:"default_target,omitempty"`
+	// Whether to create the dist dir of the target (`CLIOptions.CreateDistDir`).
+	CreateDistDir bool `json:"create_dist_dir,omitempty"`
+	// Each lib is a target. Building a target builds its dependencies first.
+	Libs []*RecipeLib `json:"libs"`
+}
+
+type RecipeRepo struct {
+	Url                  string     `json:"url,omitempty"`
+	Name                 string     `json:"name"`
+	LocalRepoDir         string     `json:"local_repo_dir,omitempty"`
+	Tag                  string     `json:"tag,omitempty"`
+	Commit               string     `json:"commit,omitempty"`
+	Branch               string     `json:"branch,omitempty"`
+	UrlArchiveName       string     `json:"url_archive_name,omitempty"`
+	PostCheckoutCommands [][]string `json:"post_checkout_commands,omitempty"`
+	SourceSubDir         []string   `json:"source_sub_dir,omitempty"`
+	Submodules           bool       `json:"submodules,omitempty"`
+	SubmodulePaths       []string   `json:"submodule_paths,omitempty"`
+	LFS                  bool       `json:"lfs,omitempty"`
+	IsolateSource        bool       `json:"isolate_source,omitempty"`
+}
+
+type RecipeLib struct {
+	Name string      `json:"name"`
+	Repo *RecipeRepo `json:"repo"`
+	// cmake, meson, make, plain_make, cargo or go.
+	BuildSystem BuildSystemEnum `json:"build_system"`
+	// static or dynamic. Defaults to the -dylib flag.
+	LibType string `json:"lib_type,omitempty"`
+	// Names of libs built before this lib.
+	Deps []string `json:"deps,omitempty"`
+
+	// Setup args (CMake generate, Meson setup or ./configure).
+	Args []string `json:"args,omitempty"`
+	// Extra args keyed by platform (macos, ios, darwin, android).
+	PlatformArgs map[PlatformEnum][]string `json:"platform_args,omitempty"`
+	// Extra args keyed by SDK (macosx, iphoneos, iphonesimulator, android).
+	SDKArgs map[SDKEnum][]string `json:"sdk_args,omitempty"`
+
+	// Setup env in `KEY=VALUE` format.
+	Env         []string                  `json:"env,omitempty"`
+	PlatformEnv map[PlatformEnum][]string `json:"platform_env,omitempty"`
+	SDKEnv      map[SDKEnum][]string      `json:"sdk_env,omitempty"`
+
+	// Make only.
+	MakeExtraCAndCXXFlags []string `json:"make_extra_c_and_cxx_flags,omitempty"`
+	MakeExtraLDFlags      []string `json:"make_extra_ld_flags,omitempty"`
+	MakeConfigSite        []string `json:"make_config_site,omitempty"`
+	MakeSkipAutoconfArgs  bool     `json:"make_skip_autoconf_args,omitempty"`
+
+	// Plain make only.
+	PlainMakeDir            string   `json:"plain_make_dir,omitempty"`
+	PlainMakeVars           []string `json:"plain_make_vars,omitempty"`
+	PlainMakeBuildTargets   []string `json:"plain_make_build_targets,omitempty"`
+	PlainMakeInstallTargets []string `json:"plain_make_install_targets,omitempty"`
+	PlainMakeUseDestDir     bool     `json:"plain_make_use_dest_dir,omitempty"`
+	PlainMakeInstallHeaders []string `json:"plain_make_install_headers,omitempty"`
+	PlainMakeInstallLibs    []string `json:"plain_make_install_libs,omitempty"`
+
+	// Cargo only.
+	CargoPackage        string `json:"cargo_package,omitempty"`
+	CargoProfile        string `json:"cargo_profile,omitempty"`
+	CargoCbindgenHeader string `json:"cargo_cbindgen_header,omitempty"`
+
+	// Go only.
+	GoPackage string   `json:"go_package,omitempty"`
+	GoTags    []string `json:"go_tags,omitempty"`
+
+	// Expected output files checked by `VerifyFile`. The first one is passed to `Project.Install`.
+	Outputs []string      `json:"outputs,omitempty"`
+	Verify  *RecipeVerify `json:"verify,omitempty"`
+}
+
+type RecipeVerify struct {
+	DistDir                  bool          `json:"dist_dir,omitempty"`
+	Dylib                    bool          `json:"dylib,omitempty"`
+	SkipDarwinSDKVerCheck    bool          `json:"skip_darwin_sdk_ver_check,omitempty"`
+	DarwinSDKVer             string        `json:"darwin_sdk_ver,omitempty"`
+	SkipAndroidPageSizeCheck bool          `json:"skip_android_page_size_check,omitempty"`
+	ExportedSymbols          *SymbolPolicy `json:"exported_symbols,omitempty"`
+}
+
+// Go hooks of a recipe lib, for the odd library that needs custom steps.
+type RecipeHooks struct {
+	// If set, replaces the recipe build of the lib.
+	Build func(be *BuildEnv, lib *RecipeLib)
+	// Called before `Project.Init`. `opt` can be modified.
+	BeforeInit func(p Project, opt *ProjectInitOptions)
+	// Called after `Project.Build`.
+	AfterBuild func(p Project)
+	// Called after `Project.Install`.
+	AfterInstall func(p Project)
+}
+
+type RunRecipeOptions struct {
+	// Optional CLI options. `AllowedTargets` and `DefaultTarget` default to recipe libs.
+	CLIOptions *CLIOptions
+	// Hooks keyed by lib name.
+	Hooks       map[string]*RecipeHooks
+	BeforeAllFn func(*Shell)
+	AfterAllFn  func(*Shell)
+}
+
+type newProjectFn func(repo *RepoInfo, buildEnv *BuildEnv, libType LibType) Project
+
+var recipeProjectTypes = map[BuildSystemEnum]newProjectFn{
+	BuildSystemCmake:     NewCMakeProject,
+	BuildSystemMeson:     NewMesonProject,
+	BuildSystemMake:      NewMakeProject,
+	BuildSystemPlainMake: NewPlainMakeProject,
+	BuildSystemCargo:     NewCargoProject,
+	BuildSystemGo:        NewGoProject,
+}
+
+func LoadRecipe(file string) (*Recipe, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("error reading recipe: %w", err)
+	}
+	var recipe Recipe
+	if err := util.DecodeJSONStrict(file, data, &recipe); err != nil {
+		return nil, err
+	}
+	if err := recipe.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return &recipe, nil
+}
+
+func (r *Recipe) Validate() error {
+	if len(r.Libs) == 0 {
+		return fmt.Errorf("no libs")
+	}
+	names := map[string]bool{}
+	for _, lib := range r.Libs {
+		if lib.Name == "" {
+			return fmt.Errorf("lib name is empty")
+		}
+		if names[lib.Name] {
+			return fmt.Errorf("duplicate lib %q", lib.Name)
+		}
+		names[lib.Name] = true
+		if lib.Repo == nil || lib.Repo.Name == "" {
+			return fmt.Errorf("%s: repo.name is required", lib.Name)
+		}
+		if lib.Repo.Url == "" && lib.Repo.LocalRepoDir == "" {
+			return fmt.Errorf("%s: repo.url or repo.local_repo_dir is required", lib.Name)
+		}
+		if recipeProjectTypes[lib.BuildSystem] == nil {
+			return fmt.Errorf("%s: unsupported build_system %q", lib.Name, lib.BuildSystem)
+		}
+		if _, err := parseRecipeLibType(lib.LibType); err != nil {
+			return fmt.Errorf("%s: %w", lib.Name, err)
+		}
+		for platform := range lib.PlatformArgs {
+			if PlatformSDKs[platform] == nil {
+				return fmt.Errorf("%s: unsupported platform %q in platform_args", lib.Name, platform)
+			}
+		}
+		for platform := range lib.PlatformEnv {
+			if PlatformSDKs[platform] == nil {
+				return fmt.Errorf("%s: unsupported platform %q in platform_env", lib.Name, platform)
+			}
+		}
+		for sdk := range lib.SDKArgs {
+			if !SupportedSDKs[sdk] {
+				return fmt.Errorf("%s: unsupported SDK %q in sdk_args", lib.Name, sdk)
+			}
+		}
+		for sdk := range lib.SDKEnv {
+			if !SupportedSDKs[sdk] {
+				return fmt.Errorf("%s: unsupported SDK %q in sdk_env", lib.Name, sdk)
+			}
+		}
+	}
+	for _, lib := range r.Libs {
+		for _, dep := range lib.Deps {
+			if !names[dep] {
+				return fmt.Errorf("%s: unknown dep %q", lib.Name, dep)
+			}
+		}
+		if _, err := r.BuildOrder(lib.Name); err != nil {
+			return err
+		}
+	}
+	if r.DefaultTarget != "" && !names[r.DefaultTarget] {
+		return fmt.Errorf("default_target %q is not a lib", r.DefaultTarget)
+	}
+	return nil
+}
+
+func (r *Recipe) Lib(name string) *RecipeLib {
+	for _, lib := range r.Libs {
+		if lib.Name == name {
+			return lib
+		}
+	}
+	return nil
+}
+
+func (r *Recipe) LibNames() []string {
+	var names []string
+	for _, lib := range r.Libs {
+		names = append(names, lib.Name)
+	}
+	return names
+}
+
+// Returns the libs to build for a target, dependencies first.
+func (r *Recipe) BuildOrder(target string) ([]*RecipeLib, error) {
+	var res []*RecipeLib
+	visited := map[string]bool{}
+	visiting := map[string]bool{}
+	var visit func(name string, chain []string) error
+	visit = func(name string, chain []string) error {
+		if visited[name] {
+			return nil
+		}
+		chain = append(chain, name)
+		if visiting[name] {
+			return fmt.Errorf("dependency cycle: %s", strings.Join(chain, " -> "))
+		}
+		lib := r.Lib(name)
+		if lib == nil {
+			return fmt.Errorf("unknown lib %q", name)
+		}
+		visiting[name] = true
+		for _, dep := range lib.Deps {
+			if err := visit(dep, chain); err != nil {
+				return err
+			}
+		}
+		visiting[name] = false
+		visited[name] = true
+		res = append(res, lib)
+		return nil
+	}
+	if err := visit(target, nil); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// Runs `StartEnvLoopWithOptions` and builds the target lib of the recipe with its dependencies.
+func RunRecipe(recipe *Recipe, opt *RunRecipeOptions) {
+	if opt == nil {
+		opt = &RunRecipeOptions{}
+	}
+	cliOpt := opt.CLIOptions
+	if cliOpt == nil {
+		cliOpt = &CLIOptions{}
+	}
+	if len(cliOpt.AllowedTargets) == 0 {
+		cliOpt.AllowedTargets = recipe.LibNames()
+	}
+	if cliOpt.DefaultTarget == "" {
+		cliOpt.DefaultTarget = recipe.DefaultTarget
+		if cliOpt.DefaultTarget == "" {
+			cliOpt.DefaultTarget = recipe.Libs[len(recipe.Libs)-1].Name
+		}
+	}
+	if recipe.CreateDistDir {
+		cliOpt.CreateDistDir = true
+	}
+
+	StartEnvLoopWithOptions(cliOpt, &StartEnvLoopOptions{
+		BeforeAllFn: opt.BeforeAllFn,
+		AfterAllFn:  opt.AfterAllFn,
+		LoopFn: func(be *BuildEnv) {
+			be.LogSummary()
+
+			libs, err := recipe.BuildOrder(be.Target)
+			if err != nil {
+				be.Shell.Quit(err.Error())
+			}
+			// Report all missing sources at once instead of failing on the first one.
+			if be.CLIArgs.Offline {
+				CheckOfflineSources(be.Shell, recipeMirrorSources(libs, true))
+			}
+			for _, lib := range libs {
+				var hooks *RecipeHooks
+				if opt.Hooks != nil {
+					hooks = opt.Hooks[lib.Name]
+				}
+				recipe.BuildLib(be, lib, hooks)
+			}
+		},
+	})
+}
+
+// Builds a single recipe lib for the given env.
+func (r *Recipe) BuildLib(be *BuildEnv, lib *RecipeLib, hooks *RecipeHooks) {
+	if hooks == nil {
+		hooks = &RecipeHooks{}
+	}
+	be.Shell.Log(j9.LogLevelInfo, fmt.Sprintf("🚕 Building recipe lib %s (%s)", lib.Name, lib.BuildSystem))
+	if hooks.Build != nil {
+		hooks.Build(be, lib)
+		return
+	}
+
+	libType, _ := parseRecipeLibType(lib.LibType)
+	if lib.LibType == "" {
+		libType = be.CLIArgs.LibType
+	}
+	expand := func(list []string) []string {
+		return be.expandRecipeStrings(list)
+	}
+
+	p := recipeProjectTypes[lib.BuildSystem](lib.Repo.toRepoInfo(expand), be, libType)
+	initOpt := &ProjectInitOptions{
+		Args:                  expand(recipeValuesForSDK(be.SDK, lib.Args, lib.PlatformArgs, lib.SDKArgs)),
+		Env:                   expand(recipeValuesForSDK(be.SDK, lib.Env, lib.PlatformEnv, lib.SDKEnv)),
+		MakeExtraCAndCXXFlags: expand(lib.MakeExtraCAndCXXFlags),
+		MakeExtraLDFlags:      expand(lib.MakeExtraLDFlags),
+		MakeConfigSite:        expand(lib.MakeConfigSite),
+		MakeSkipAutoconfArgs:  lib.MakeSkipAutoconfArgs,
+
+		PlainMakeDir:            lib.PlainMakeDir,
+		PlainMakeVars:           expand(lib.PlainMakeVars),
+		PlainMakeBuildTargets:   expand(lib.PlainMakeBuildTargets),
+		PlainMakeInstallTargets: expand(lib.PlainMakeInstallTargets),
+		PlainMakeUseDestDir:     lib.PlainMakeUseDestDir,
+		PlainMakeInstallHeaders: expand(lib.PlainMakeInstallHeaders),
+		PlainMakeInstallLibs:    expand(lib.PlainMakeInstallLibs),
+
+		CargoPackage:        lib.CargoPackage,
+		CargoProfile:        lib.CargoProfile,
+		CargoCbindgenHeader: lib.CargoCbindgenHeader,
+
+		GoPackage: lib.GoPackage,
+		GoTags:    lib.GoTags,
+	}
+	if hooks.BeforeInit != nil {
+		hooks.BeforeInit(p, initOpt)
+	}
+	p.Init(initOpt)
+	p.Build()
+	if hooks.AfterBuild != nil {
+		hooks.AfterBuild(p)
+	}
+
+	outputs := expand(lib.Outputs)
+	vfOpt := lib.Verify.toVerifyFileOptions()
+	var installFile string
+	if len(outputs) > 0 {
+		installFile = outputs[0]
+	}
+	p.Install(installFile, vfOpt)
+	for i := 1; i < len(outputs); i++ {
+		be.VerifyFile(outputs[i], vfOpt)
+	}
+	if hooks.AfterInstall != nil {
+		hooks.AfterInstall(p)
+	}
+}
+
+// Returns common values, then platform values, then SDK values.
+func recipeValuesForSDK(sdk SDKEnum, common []string, platformValues map[PlatformEnum][]string, sdkValues map[SDKEnum][]string) []string {
+	res := append([]string{}, common...)
+	for _, platform := range []PlatformEnum{PlatformDarwin, PlatformMacos, PlatformIos, PlatformAndroid} {
+		values, ok := platformValues[platform]
+		if ok && slices.Contains(PlatformSDKs[platform], sdk) {
+			res = append(res, values...)
+		}
+	}
+	res = append(res, sdkValues[sdk]...)
+	return res
+}
+
+// Returns mirror sources of the libs. If `needFetchOnly` is true, libs already checked out are skipped.
+func recipeMirrorSources(libs []*RecipeLib, needFetchOnly bool) []MirrorSource {
+	var res []MirrorSource
+	for _, lib := range libs {
+		repo := lib.Repo.toRepoInfo(func(list []string) []string { return list })
+		src := repo.MirrorSource()
+		if src == nil {
+			continue
+		}
+		if needFetchOnly {
+			if empty, err := io2.IsDirectoryEmpty(generateRepoRootDir(repo)); err == nil && !empty {
+				continue
+			}
+		}
+		res = append(res, *src)
+	}
+	return res
+}
+
+// Returns mirror sources of all libs of the recipe.
+func (r *Recipe) MirrorSources() []MirrorSource {
+	return recipeMirrorSources(r.Libs, false)
+}
+
+func (r *RecipeRepo) toRepoInfo(expand func([]string) []string) *RepoInfo {
+	var commands [][]string
+	for _, cmd := range r.PostCheckoutCommands {
+		commands = append(commands, expand(cmd))
+	}
+	return &RepoInfo{
+		Url:                  r.Url,
+		Name:                 r.Name,
+		LocalRepoDir:         r.LocalRepoDir,
+		Tag:                  r.Tag,
+		Commit:               r.Commit,
+		Branch:               r.Branch,
+		UrlArchiveName:       r.UrlArchiveName,
+		PostCheckoutCommands: commands,
+		SourceSubDir:         r.SourceSubDir,
+		Submodules:           r.Submodules,
+		SubmodulePaths:       r.SubmodulePaths,
+		LFS:                  r.LFS,
+		IsolateSource:        r.IsolateSource,
+	}
+}
+
+func (v *RecipeVerify) toVerifyFileOptions() *VerifyFileOptions {
+	if v == nil {
+		return nil
+	}
+	return &VerifyFileOptions{
+		DistDir:                  v.DistDir,
+		Dylib:                    v.Dylib,
+		SkipDarwinSDKVerCheck:    v.SkipDarwinSDKVerCheck,
+		DarwinSDKVer:             v.DarwinSDKVer,
+		SkipAndroidPageSizeCheck: v.SkipAndroidPageSizeCheck,
+		SymbolPolicy:             v.ExportedSymbols,
+	}
+}
+
+func parseRecipeLibType(s string) (LibType, error) {
+	switch s {
+	case "", "static":
+		return LibTypeStatic, nil
+	case "dynamic":
+		return LibTypeDynamic, nil
+	}
+	return LibTypeStatic, fmt.Errorf("unsupported lib_type %q", s)
+}
+
+// Expands `${VAR}` in recipe strings. Quits on undefined variables.
+func (be *BuildEnv) expandRecipeStrings(list []string) []string {
+	vars := map[string]string{
+		"KU_SDK":              string(be.SDK),
+		"KU_ARCH":             string(be.Arch),
+		"KU_ARCH_DIR":         be.ArchDir,
+		"KU_TARGET":           be.Target,
+		"KU_TARGET_LIB_NAME":  be.TargetLibName,
+		"KU_TARGET_DIR":       be.TargetDir,
+		"KU_OUT_DIR":          be.OutDir,
+		"KU_OUT_INCLUDE_DIR":  be.OutIncludeDir,
+		"KU_OUT_LIB_DIR":      be.OutLibDir,
+		"KU_DIST_DIR":         be.DistDir,
+		"KU_DIST_INCLUDE_DIR": be.DistIncludeDir,
+		"KU_DIST_LIB_DIR":     be.DistLibDir,
+	}
+	var res []string
+	for _, s := range list {
+		res = append(res, os.Expand(s, func(name string) string {
+			if v, ok := vars[name]; ok {
+				return v
+			}
+			v, ok := os.LookupEnv(name)
+			if !ok {
+				be.Shell.Quit(fmt.Sprintf("Undefined variable %s in recipe value %q", name, s))
+			}
+			return v
+		}))
+	}
+	return res
+}
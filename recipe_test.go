@@ -0,0 +1,136 @@
+package ku
+
+import (
+	"strings"
+	"testing"
+)
+
+func testRecipeLib(name string, deps ...string) *RecipeLib {
+	return &RecipeLib{
+		Name:        name,
+		Repo:        &RecipeRepo{Name: name, Url: "https://example.com/" + name + ".git"},
+		BuildSystem: BuildSystemCmake,
+		Deps:        deps,
+	}
+}
+
+func TestRecipeBuildOrder(t *testing.T) {
+	tests := []struct {
+		name   string
+		libs   []*RecipeLib
+		target string
+		want   string
+		err    string
+	}{
+		{
+			name:   "no deps",
+			libs:   []*RecipeLib{testRecipeLib("zlib")},
+			target: "zlib",
+			want:   "zlib",
+		},
+		{
+			name:   "deps first",
+			libs:   []*RecipeLib{testRecipeLib("app", "png", "zlib"), testRecipeLib("png", "zlib"), testRecipeLib("zlib")},
+			target: "app",
+			want:   "zlib,png,app",
+		},
+		{
+			name:   "unrelated libs are skipped",
+			libs:   []*RecipeLib{testRecipeLib("png", "zlib"), testRecipeLib("zlib"), testRecipeLib("ssl")},
+			target: "png",
+			want:   "zlib,png",
+		},
+		{
+			name:   "self cycle",
+			libs:   []*RecipeLib{testRecipeLib("a", "a")},
+			target: "a",
+			err:    "dependency cycle: a -> a",
+		},
+		{
+			name:   "indirect cycle",
+			libs:   []*RecipeLib{testRecipeLib("a", "b"), testRecipeLib("b", "c"), testRecipeLib("c", "a")},
+			target: "a",
+			err:    "dependency cycle: a -> b -> c -> a",
+		},
+		{
+			name:   "unknown dep",
+			libs:   []*RecipeLib{testRecipeLib("a", "b")},
+			target: "a",
+			err:    `unknown lib "b"`,
+		},
+		{
+			name:   "unknown target",
+			libs:   []*RecipeLib{testRecipeLib("a")},
+			target: "b",
+			err:    `unknown lib "b"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &Recipe{Libs: tt.libs}
+			libs, err := r.BuildOrder(tt.target)
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Fatalf("expected error %q, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var names []string
+			for _, lib := range libs {
+				names = append(names, lib.Name)
+			}
+			if got := strings.Join(names, ","); got != tt.want {
+				t.Fatalf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRecipeValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		libs []*RecipeLib
+		err  string
+	}{
+		{
+			name: "valid",
+			libs: []*RecipeLib{testRecipeLib("png", "zlib"), testRecipeLib("zlib")},
+		},
+		{
+			name: "unknown dep",
+			libs: []*RecipeLib{testRecipeLib("png", "zlib")},
+			err:  `png: unknown dep "zlib"`,
+		},
+		{
+			name: "cycle",
+			libs: []*RecipeLib{testRecipeLib("a", "b"), testRecipeLib("b", "a")},
+			err:  "dependency cycle: a -> b -> a",
+		},
+		{
+			name: "duplicate lib",
+			libs: []*RecipeLib{testRecipeLib("a"), testRecipeLib("a")},
+			err:  `duplicate lib "a"`,
+		},
+		{
+			name: "no libs",
+			err:  "no libs",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := (&Recipe{Libs: tt.libs}).Validate()
+			if tt.err == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.err {
+				t.Fatalf("expected error %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
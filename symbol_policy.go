@@ -0,0 +1,90 @@
+package ku
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/mgenware/j9/v3"
+	"github.com/mgenware/ku-builder/objfile"
+)
+
+// Max number of violations printed when a symbol policy check fails.
+const maxSymbolPolicyViolationsLogged = 50
+
+// Declarative policy of exported symbols, checked by `VerifyFile` after install.
+// Patterns use `path.Match` syntax and are matched against symbol names without the Mach-O leading underscore,
+// e.g. `png_*` matches `_png_read_info` in Mach-O files and `png_read_info` in ELF files.
+type SymbolPolicy struct {
+	// If not empty, every exported symbol must match at least one of these patterns.
+	Allow []string `json:"allow,omitempty"`
+	// Exported symbols must not match any of these patterns. Takes precedence over `Allow`.
+	// Example: `_ZN*` (C++ symbols from bundled libc++), `png_*` (symbols from transitive static deps).
+	Deny []string `json:"deny,omitempty"`
+}
+
+// Returns the symbols that violate the policy with reasons.
+func (p *SymbolPolicy) Violations(symbols []string) ([]string, error) {
+	var violations []string
+	for _, sym := range symbols {
+		denied, err := matchAnyPattern(p.Deny, sym)
+		if err != nil {
+			return nil, err
+		}
+		if denied != "" {
+			violations = append(violations, fmt.Sprintf("%s (denied by %s)", sym, denied))
+			continue
+		}
+		if len(p.Allow) == 0 {
+			continue
+		}
+		allowed, err := matchAnyPattern(p.Allow, sym)
+		if err != nil {
+			return nil, err
+		}
+		if allowed == "" {
+			violations = append(violations, fmt.Sprintf("%s (not in allow-list)", sym))
+		}
+	}
+	return violations, nil
+}
+
+// Returns the first matched pattern or an empty string.
+func matchAnyPattern(patterns []string, s string) (string, error) {
+	for _, pattern := range patterns {
+		matched, err := path.Match(pattern, s)
+		if err != nil {
+			return "", fmt.Errorf("invalid symbol pattern %s: %w", pattern, err)
+		}
+		if matched {
+			return pattern, nil
+		}
+	}
+	return "", nil
+}
+
+func (e *OSEnv) VerifyExportedSymbols(file string, policy *SymbolPolicy) {
+	logger := e.shell.Logger()
+
+	logger.Log(j9.LogLevelVerbose, "🔍 Verifying exported symbols for file "+file)
+	symbols, err := objfile.ReadExportedSymbols(file)
+	if err != nil {
+		e.shell.Quit(fmt.Sprintf("Error reading exported symbols: %v", err))
+	}
+	violations, err := policy.Violations(symbols)
+	if err != nil {
+		e.shell.Quit(err.Error())
+	}
+	if len(violations) > 0 {
+		logged := violations
+		if len(logged) > maxSymbolPolicyViolationsLogged {
+			logged = logged[:maxSymbolPolicyViolationsLogged]
+		}
+		msg := fmt.Sprintf("%d exported symbol(s) violate the symbol policy for file %s:\n  %s", len(violations), file, strings.Join(logged, "\n  "))
+		if len(logged) < len(violations) {
+			msg += fmt.Sprintf("\n  ... and %d more", len(violations)-len(logged))
+		}
+		e.shell.Quit(msg)
+	}
+	logger.Log(j9.LogLevelSuccess, fmt.Sprintf("✅ Exported symbols verified for file %s, %d symbol(s)", file, len(symbols)))
+}
@@ -0,0 +1,67 @@
+package ku
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSymbolPolicyViolations(t *testing.T) {
+	symbols := []string{"foo_init", "foo_read", "png_read_info", "_ZN3foo3barEv"}
+	tests := []struct {
+		name   string
+		policy *SymbolPolicy
+		want   []string
+		err    bool
+	}{
+		{
+			name:   "empty policy",
+			policy: &SymbolPolicy{},
+		},
+		{
+			name:   "allow only",
+			policy: &SymbolPolicy{Allow: []string{"foo_*"}},
+			want:   []string{"png_read_info (not in allow-list)", "_ZN3foo3barEv (not in allow-list)"},
+		},
+		{
+			name:   "deny only",
+			policy: &SymbolPolicy{Deny: []string{"png_*", "_ZN*"}},
+			want:   []string{"png_read_info (denied by png_*)", "_ZN3foo3barEv (denied by _ZN*)"},
+		},
+		{
+			name:   "deny takes precedence over allow",
+			policy: &SymbolPolicy{Allow: []string{"foo_*", "png_*"}, Deny: []string{"foo_read", "png_*"}},
+			want: []string{
+				"foo_read (denied by foo_read)",
+				"png_read_info (denied by png_*)",
+				"_ZN3foo3barEv (not in allow-list)",
+			},
+		},
+		{
+			name:   "first matching deny pattern is reported",
+			policy: &SymbolPolicy{Deny: []string{"foo_r*", "foo_*"}},
+			want:   []string{"foo_init (denied by foo_*)", "foo_read (denied by foo_r*)"},
+		},
+		{
+			name:   "invalid pattern",
+			policy: &SymbolPolicy{Deny: []string{"foo_["}},
+			err:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.policy.Violations(symbols)
+			if tt.err {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,241 @@
+package ku
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/mgenware/j9/v3"
+	"github.com/mgenware/ku-builder/io2"
+)
+
+type SyncOptions struct {
+	Shell *Shell
+	// Only copies files whose content (SHA-256) or mode differs, and removes files missing in the source.
+	// Otherwise the destination is replaced like `CPToDirByForce`.
+	Incremental bool
+	// Only logs what would be changed.
+	DryRun bool
+}
+
+// Copies a file or dir into `dstDir`. Returns the number of changed files.
+// `opt` defaults to a full copy logged to the console.
+func SyncToDir(src string, dstDir string, opt *SyncOptions) int {
+	if opt == nil {
+		opt = &SyncOptions{}
+	}
+	if opt.Shell == nil {
+		withShell := *opt
+		withShell.Shell = NewShell(CreateDefaultTunnel(), nil)
+		opt = &withShell
+	}
+	shell := opt.Shell
+	srcInfo, err := os.Lstat(src)
+	if err != nil {
+		shell.Quit(fmt.Sprintf("Error reading sync source: %v", err))
+	}
+
+	if !opt.Incremental {
+		if opt.DryRun {
+			shell.Log(j9.LogLevelInfo, fmt.Sprintf("[dry-run] Copy %s to %s", src, dstDir))
+			return 1
+		}
+		io2.Mkdirp(dstDir)
+		CPToDirByForce(shell, src, srcInfo.IsDir(), dstDir)
+		return 1
+	}
+
+	s := &syncer{opt: opt, src: src, dst: filepath.Join(dstDir, filepath.Base(src))}
+	if !opt.DryRun {
+		io2.Mkdirp(dstDir)
+	}
+	if err := s.run(srcInfo); err != nil {
+		shell.Quit(fmt.Sprintf("Error syncing %s to %s: %v", src, dstDir, err))
+	}
+	if s.changed == 0 {
+		shell.Log(j9.LogLevelVerbose, fmt.Sprintf("Up to date: %s", s.dst))
+	}
+	return s.changed
+}
+
+type syncer struct {
+	opt     *SyncOptions
+	src     string
+	dst     string
+	changed int
+}
+
+func (s *syncer) log(action, path string) {
+	s.changed++
+	msg := fmt.Sprintf("%s %s", action, path)
+	if s.opt.DryRun {
+		msg = "[dry-run] " + msg
+	}
+	s.opt.Shell.Log(j9.LogLevelInfo, msg)
+}
+
+func (s *syncer) run(srcInfo fs.FileInfo) error {
+	if !srcInfo.IsDir() {
+		return s.syncEntry(s.src, s.dst, srcInfo)
+	}
+
+	srcPaths := map[string]bool{}
+	err := filepath.WalkDir(s.src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(s.src, path)
+		if err != nil {
+			return err
+		}
+		srcPaths[rel] = true
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		return s.syncEntry(path, filepath.Join(s.dst, rel), info)
+	})
+	if err != nil {
+		return err
+	}
+
+	// Remove stale files.
+	if _, err := os.Lstat(s.dst); err != nil {
+		return nil
+	}
+	return filepath.WalkDir(s.dst, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(s.dst, path)
+		if err != nil {
+			return err
+		}
+		if srcPaths[rel] {
+			return nil
+		}
+		s.log("Remove", path)
+		if !s.opt.DryRun {
+			if err := os.RemoveAll(path); err != nil {
+				return err
+			}
+		}
+		if d.IsDir() {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+}
+
+func (s *syncer) syncEntry(src, dst string, srcInfo fs.FileInfo) error {
+	dstInfo, dstErr := os.Lstat(dst)
+	if dstErr == nil && dstInfo.Mode().Type() != srcInfo.Mode().Type() {
+		// File type changed, e.g. a file replaced by a symlink.
+		s.log("Remove", dst)
+		if !s.opt.DryRun {
+			if err := os.RemoveAll(dst); err != nil {
+				return err
+			}
+		}
+		dstErr = os.ErrNotExist
+	}
+
+	switch {
+	case srcInfo.IsDir():
+		if dstErr != nil {
+			s.log("Create", dst)
+			if !s.opt.DryRun {
+				return os.MkdirAll(dst, srcInfo.Mode().Perm())
+			}
+		}
+		return nil
+
+	case srcInfo.Mode()&fs.ModeSymlink != 0:
+		link, err := os.Readlink(src)
+		if err != nil {
+			return err
+		}
+		if dstErr == nil {
+			if dstLink, err := os.Readlink(dst); err == nil && dstLink == link {
+				return nil
+			}
+		}
+		s.log("Link", dst)
+		if s.opt.DryRun {
+			return nil
+		}
+		os.Remove(dst)
+		return os.Symlink(link, dst)
+
+	default:
+		if dstErr == nil && dstInfo.Mode().Perm() == srcInfo.Mode().Perm() && dstInfo.Size() == srcInfo.Size() {
+			same, err := sameFileHash(src, dst)
+			if err != nil {
+				return err
+			}
+			if same {
+				return nil
+			}
+		}
+		s.log("Copy", dst)
+		if s.opt.DryRun {
+			return nil
+		}
+		return copyFileAtomic(src, dst, srcInfo.Mode().Perm())
+	}
+}
+
+// Returns the SHA-256 of a file.
+func FileHash(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+func sameFileHash(a, b string) (bool, error) {
+	ha, err := FileHash(a)
+	if err != nil {
+		return false, err
+	}
+	hb, err := FileHash(b)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(ha, hb), nil
+}
+
+func copyFileAtomic(src, dst string, perm fs.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	tmp, err := os.CreateTemp(filepath.Dir(dst), ".ku-sync-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, in); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), dst)
+}
@@ -0,0 +1,116 @@
+package ku
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeTestFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// Returns relative paths and contents of all files in `dir`.
+func readTestFiles(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	files := map[string]string{}
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, path)
+		files[rel] = string(data)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func TestSyncToDir(t *testing.T) {
+	initial := map[string]string{"a.h": "a", "sub/b.h": "b", "stale.h": "old"}
+	tests := []struct {
+		name    string
+		src     map[string]string
+		dryRun  bool
+		want    map[string]string
+		changed int
+	}{
+		{
+			name:    "up to date",
+			src:     map[string]string{"a.h": "a", "sub/b.h": "b", "stale.h": "old"},
+			want:    initial,
+			changed: 0,
+		},
+		{
+			name:    "copies changed files only",
+			src:     map[string]string{"a.h": "a2", "sub/b.h": "b", "stale.h": "old"},
+			want:    map[string]string{"a.h": "a2", "sub/b.h": "b", "stale.h": "old"},
+			changed: 1,
+		},
+		{
+			name:    "adds new files and removes stale files",
+			src:     map[string]string{"a.h": "a", "sub/b.h": "b", "sub/c.h": "c"},
+			want:    map[string]string{"a.h": "a", "sub/b.h": "b", "sub/c.h": "c"},
+			changed: 2,
+		},
+		{
+			name:    "dry run",
+			src:     map[string]string{"a.h": "a2", "sub/c.h": "c"},
+			dryRun:  true,
+			want:    initial,
+			changed: 4,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			src := filepath.Join(root, "src", "include")
+			dstDir := filepath.Join(root, "dst")
+			writeTestFiles(t, src, tt.src)
+			writeTestFiles(t, filepath.Join(dstDir, "include"), initial)
+
+			changed := SyncToDir(src, dstDir, &SyncOptions{Incremental: true, DryRun: tt.dryRun})
+			if changed != tt.changed {
+				t.Errorf("expected %d changes, got %d", tt.changed, changed)
+			}
+			got := readTestFiles(t, filepath.Join(dstDir, "include"))
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+			for name, content := range tt.want {
+				if got[name] != content {
+					t.Fatalf("expected %v, got %v", tt.want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestSyncToDirDryRunCreatesNothing(t *testing.T) {
+	root := t.TempDir()
+	src := filepath.Join(root, "include")
+	writeTestFiles(t, src, map[string]string{"a.h": "a"})
+	dstDir := filepath.Join(root, "dst")
+
+	if changed := SyncToDir(src, dstDir, &SyncOptions{Incremental: true, DryRun: true}); changed != 2 {
+		t.Fatalf("expected 2 changes, got %d", changed)
+	}
+	if _, err := os.Stat(dstDir); !os.IsNotExist(err) {
+		t.Fatalf("expected %s not to be created, got %v", dstDir, err)
+	}
+}
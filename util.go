@@ -82,7 +82,7 @@ func CopyJNILibs(shell *Shell, libFileNames []string, headerFileNames []string)
 	cliArgs := shell.Args
 	target := cliArgs.Target
 	debug := cliArgs.DebugBuild
-	buildTypeDir := GetBuildTypeDir(debug)
+	buildTypeDir := GetProfileBuildTypeDir(debug, cliArgs.Profile)
 	sdkDir := GetSDKDir(buildTypeDir, SDKAndroid)
 
 	jniBuildDir := filepath.Join(sdkDir, "jni", target)
@@ -97,6 +97,7 @@ func CopyJNILibs(shell *Shell, libFileNames []string, headerFileNames []string)
 		HeaderFileNames: headerFileNames,
 		Target:          target,
 		Debug:           debug,
+		Profile:         cliArgs.Profile,
 	})
 }
 
@@ -108,7 +109,15 @@ type CopyJNILibsOptions struct {
 	HeaderFileNames []string
 	Target          string
 	Debug           bool
-	KuDeploy        bool
+	// Build profile name, see `BuildProfile`.
+	Profile  string
+	KuDeploy bool
+	// Copy static libs (.a, e.g. from `MergeStaticLibs`) instead of shared libs (.so).
+	StaticLibs bool
+	// Only copy changed files, see `SyncOptions.Incremental`.
+	Incremental bool
+	// Only log what would be copied.
+	DryRun bool
 }
 
 func CopyJNILibsCore(opt *CopyJNILibsOptions) {
@@ -123,16 +132,32 @@ func CopyJNILibsCore(opt *CopyJNILibsOptions) {
 	target := opt.Target
 	debug := opt.Debug
 
-	buildTypeDir := GetBuildTypeDir(debug)
+	buildTypeDir := GetProfileBuildTypeDir(debug, opt.Profile)
 	sdkDir := GetSDKDir(buildTypeDir, SDKAndroid)
 
+	manifest, err := ReadBuildManifest(buildTypeDir, target)
+	if err != nil {
+		shell.Quit(err.Error())
+	}
+	var sanitizer string
+	if manifest != nil {
+		sanitizer = manifest.Sanitizer
+	}
+
 	if opt.KuDeploy {
 		shell.Log(j9.LogLevelInfo, fmt.Sprintf("Copying JNI libs for target=%s, debug=%v, dstLibsDir=%s, dstIncludeDir=%s, libFileNames=%v, headerFileNames=%v", target, debug, dstLibsDir, dstIncludeDir, libFileNames, headerFileNames))
 	}
 
-	io2.Mkdirp(dstLibsDir)
-	if dstIncludeDir != "" {
-		io2.Mkdirp(dstIncludeDir)
+	syncOpt := &SyncOptions{
+		Shell:       shell,
+		Incremental: opt.Incremental,
+		DryRun:      opt.DryRun,
+	}
+	if !opt.DryRun {
+		io2.Mkdirp(dstLibsDir)
+		if dstIncludeDir != "" {
+			io2.Mkdirp(dstIncludeDir)
+		}
 	}
 
 	// Copy the JNI libs to the jniLibs directory.
@@ -147,26 +172,29 @@ func CopyJNILibsCore(opt *CopyJNILibsOptions) {
 			targetDir := filepath.Join(archDir, target)
 			targetDistDir := GetTargetDistDir(targetDir)
 			srcLibFile := filepath.Join(targetDistDir, "lib", libFileName)
-			if !strings.HasSuffix(srcLibFile, ".so") {
-				srcLibFile += ".so"
+			libExt := ".so"
+			if opt.StaticLibs {
+				libExt = ".a"
 			}
-
-			var jniArch string
-			if arch == ArchArm64 {
-				jniArch = "arm64-v8a"
-			} else {
-				jniArch = "x86_64"
+			if !strings.HasSuffix(srcLibFile, libExt) {
+				srcLibFile += libExt
 			}
-			jniArchDir := filepath.Join(dstLibsDir, jniArch)
-			io2.Mkdirp(jniArchDir)
+
+			jniArchDir := filepath.Join(dstLibsDir, GetABI(arch))
 
 			// Copy the lib file to the jniLibs directory.
-			CPToDirByForce(shell, srcLibFile, false, jniArchDir)
+			SyncToDir(srcLibFile, jniArchDir, syncOpt)
 
-			if opt.KuDeploy {
+			if opt.KuDeploy && !opt.DryRun {
 				shell.Log(j9.LogLevelInfo, fmt.Sprintf("✅ Deployed %s to %s", libFileName, jniArchDir))
 			}
 		}
+
+		if sanitizer != "" {
+			archDir := GetSDKArchDir(sdkDir, arch)
+			srcLibDir := filepath.Join(GetTargetDistDir(filepath.Join(archDir, target)), "lib")
+			deployAndroidSanitizerRuntime(sanitizer, srcLibDir, dstLibsDir, filepath.Join(dstLibsDir, GetABI(arch)), syncOpt)
+		}
 	}
 
 	arm64ArchDir := GetSDKArchDir(sdkDir, ArchArm64)
@@ -177,8 +205,7 @@ func CopyJNILibsCore(opt *CopyJNILibsOptions) {
 		srcHeaderFile := filepath.Join(headerSrcDir, headerFileName)
 
 		// Copy the header file to the include directory.
-		isSrcDir := io2.DirectoryExists(srcHeaderFile)
-		CPToDirByForce(shell, srcHeaderFile, isSrcDir, dstIncludeDir)
+		SyncToDir(srcHeaderFile, dstIncludeDir, syncOpt)
 	}
 }
 
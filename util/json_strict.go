@@ -0,0 +1,277 @@
+package util
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// A JSON decoding error with the position of the offending token.
+type JSONError struct {
+	File   string
+	Line   int
+	Column int
+	Msg    string
+}
+
+func (e *JSONError) Error() string {
+	return fmt.Sprintf("%s:%d:%d: %s", e.File, e.Line, e.Column, e.Msg)
+}
+
+// Decodes JSON into `v` and rejects unknown keys and mismatched types with line/column errors.
+// `encoding/json` doesn't report positions of unknown fields, so the token stream is walked against the type first.
+func DecodeJSONStrict(file string, data []byte, v interface{}) error {
+	w := &jsonWalker{file: file, data: data, dec: json.NewDecoder(bytes.NewReader(data))}
+	w.dec.UseNumber()
+	if err := w.walk(reflect.TypeOf(v).Elem(), ""); err != nil {
+		return err
+	}
+	off := w.nextOffset()
+	if _, err := w.dec.Token(); err != io.EOF {
+		return w.errorAt(off, "unexpected data after the root object")
+	}
+	return json.Unmarshal(data, v)
+}
+
+type jsonWalker struct {
+	file string
+	data []byte
+	dec  *json.Decoder
+}
+
+// Returns the offset of the next token.
+func (w *jsonWalker) nextOffset() int {
+	off := int(w.dec.InputOffset())
+	for off < len(w.data) && strings.IndexByte(" \t\r\n,:", w.data[off]) >= 0 {
+		off++
+	}
+	return off
+}
+
+func (w *jsonWalker) errorAt(offset int, msg string) *JSONError {
+	line, col := 1, 1
+	for _, c := range w.data[:min(offset, len(w.data))] {
+		if c == '\n' {
+			line++
+			col = 1
+		} else {
+			col++
+		}
+	}
+	return &JSONError{File: w.file, Line: line, Column: col, Msg: msg}
+}
+
+func (w *jsonWalker) token() (json.Token, int, error) {
+	off := w.nextOffset()
+	tok, err := w.dec.Token()
+	if err != nil {
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			return nil, off, w.errorAt(int(syntaxErr.Offset), syntaxErr.Error())
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil, off, w.errorAt(len(w.data), "unexpected end of JSON input")
+		}
+		return nil, off, w.errorAt(off, err.Error())
+	}
+	return tok, off, nil
+}
+
+func (w *jsonWalker) walk(t reflect.Type, path string) error {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	tok, off, err := w.token()
+	if err != nil {
+		return err
+	}
+	name := path
+	if name == "" {
+		name = "root"
+	}
+	typeErr := func() error {
+		return w.errorAt(off, fmt.Sprintf("%s: expected %s, got %s", name, JSONTypeName(t), tokenTypeName(tok)))
+	}
+
+	switch t.Kind() {
+	case reflect.Struct, reflect.Map:
+		if tok != json.Delim('{') {
+			return typeErr()
+		}
+		var fields map[string]reflect.Type
+		if t.Kind() == reflect.Struct {
+			fields = jsonFields(t)
+		}
+		for w.dec.More() {
+			keyTok, keyOff, err := w.token()
+			if err != nil {
+				return err
+			}
+			key := keyTok.(string)
+			var valueType reflect.Type
+			if fields == nil {
+				valueType = t.Elem()
+			} else {
+				var ok bool
+				valueType, ok = fields[key]
+				if !ok {
+					msg := fmt.Sprintf("unknown key %q in %s", key, name)
+					if s := suggestKey(key, fields); s != "" {
+						msg += fmt.Sprintf(", did you mean %q?", s)
+					}
+					return w.errorAt(keyOff, msg)
+				}
+			}
+			if err := w.walk(valueType, joinJSONPath(path, key)); err != nil {
+				return err
+			}
+		}
+		_, _, err := w.token()
+		return err
+
+	case reflect.Slice:
+		if tok != json.Delim('[') {
+			return typeErr()
+		}
+		for i := 0; w.dec.More(); i++ {
+			if err := w.walk(t.Elem(), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+		_, _, err := w.token()
+		return err
+
+	case reflect.String:
+		if _, ok := tok.(string); !ok {
+			return typeErr()
+		}
+	case reflect.Bool:
+		if _, ok := tok.(bool); !ok {
+			return typeErr()
+		}
+	case reflect.Int, reflect.Int64, reflect.Uint64, reflect.Float64:
+		if _, ok := tok.(json.Number); !ok {
+			return typeErr()
+		}
+	case reflect.Interface:
+		// Any value is allowed, skip nested tokens.
+		if tok == json.Delim('{') || tok == json.Delim('[') {
+			for depth := 1; depth > 0; {
+				tok, _, err := w.token()
+				if err != nil {
+					return err
+				}
+				switch tok {
+				case json.Delim('{'), json.Delim('['):
+					depth++
+				case json.Delim('}'), json.Delim(']'):
+					depth--
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func joinJSONPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// Returns JSON keys of exported struct fields.
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := map[string]reflect.Type{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if name := JSONFieldName(f); name != "" {
+			fields[name] = f.Type
+		}
+	}
+	return fields
+}
+
+// Returns the JSON key of a struct field, or an empty string if the field is not encoded.
+func JSONFieldName(f reflect.StructField) string {
+	if !f.IsExported() {
+		return ""
+	}
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		return f.Name
+	}
+	return name
+}
+
+// Returns the JSON Schema type name of a Go type.
+func JSONTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Struct, reflect.Map:
+		return "object"
+	case reflect.Slice:
+		return "array"
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	default:
+		return "number"
+	}
+}
+
+func tokenTypeName(tok json.Token) string {
+	switch tok := tok.(type) {
+	case json.Delim:
+		if tok == '{' {
+			return "object"
+		}
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case json.Number:
+		return "number"
+	default:
+		return "null"
+	}
+}
+
+// Returns the closest known key if it's a likely typo.
+func suggestKey(key string, fields map[string]reflect.Type) string {
+	best, bestDist := "", 3
+	for name := range fields {
+		if d := editDistance(key, name); d < bestDist || (d == bestDist && name < best) {
+			best, bestDist = name, d
+		}
+	}
+	return best
+}
+
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
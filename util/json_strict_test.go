@@ -0,0 +1,87 @@
+package util
+
+import (
+	"errors"
+	"testing"
+)
+
+type testJSONTarget struct {
+	Names []string `json:"names"`
+	Dir   string   `json:"dir"`
+}
+
+type testJSONConfig struct {
+	Default string                     `json:"default"`
+	Targets map[string]*testJSONTarget `json:"targets"`
+	Debug   bool                       `json:"debug"`
+	Extra   any                        `json:"extra"`
+}
+
+func TestDecodeJSONStrict(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		// Expected error, nil if decoding succeeds.
+		err *JSONError
+	}{
+		{
+			name: "valid",
+			data: `{"default": "app", "targets": {"app": {"names": ["libz"], "dir": "/tmp"}}, "extra": {"a": [1, {}]}}`,
+		},
+		{
+			name: "unknown root key",
+			data: "{\n  \"default\": \"app\",\n  \"debgu\": true\n}",
+			err:  &JSONError{Line: 3, Column: 3, Msg: `unknown key "debgu" in root, did you mean "debug"?`},
+		},
+		{
+			name: "unknown nested key",
+			data: "{\n  \"targets\": {\n    \"app\": {\"names\": [], \"dest\": \"x\"}\n  }\n}",
+			err:  &JSONError{Line: 3, Column: 26, Msg: `unknown key "dest" in targets.app`},
+		},
+		{
+			name: "unknown key without suggestion",
+			data: `{"something_else": 1}`,
+			err:  &JSONError{Line: 1, Column: 2, Msg: `unknown key "something_else" in root`},
+		},
+		{
+			name: "type mismatch",
+			data: "{\n  \"debug\": \"yes\"\n}",
+			err:  &JSONError{Line: 2, Column: 12, Msg: `debug: expected boolean, got string`},
+		},
+		{
+			name: "type mismatch in array",
+			data: `{"targets": {"app": {"names": ["libz", 1]}}}`,
+			err:  &JSONError{Line: 1, Column: 40, Msg: `targets.app.names[1]: expected string, got number`},
+		},
+		{
+			name: "trailing data",
+			data: `{"debug": true} {}`,
+			err:  &JSONError{Line: 1, Column: 17, Msg: `unexpected data after the root object`},
+		},
+		{
+			name: "unexpected end",
+			data: "{\n  \"debug\": true",
+			err:  &JSONError{Line: 2, Column: 16, Msg: `unexpected end of JSON input`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var config testJSONConfig
+			err := DecodeJSONStrict("test.json", []byte(tt.data), &config)
+			if tt.err == nil {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			var jsonErr *JSONError
+			if !errors.As(err, &jsonErr) {
+				t.Fatalf("expected JSONError, got %v", err)
+			}
+			tt.err.File = "test.json"
+			if *jsonErr != *tt.err {
+				t.Fatalf("expected %q, got %q", tt.err, jsonErr)
+			}
+		})
+	}
+}
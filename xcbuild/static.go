@@ -0,0 +1,94 @@
+package xcbuild
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/mgenware/j9/v3"
+	"github.com/mgenware/ku-builder"
+	"github.com/mgenware/ku-builder/io2"
+)
+
+// Creates library xcframeworks (`-library` + `-headers`) from static libs in dist lib dirs.
+// Returns the list of created xcframeworks.
+func buildStaticXCFrameworks(shell *ku.Shell, opt *XCBuildOptions, xcCtx *XCContext, sdks []ku.SDKEnum, buildTypeDir string, xcDir string, moduleMapTargetLibNames map[string]bool) []string {
+	target := xcCtx.Target
+	io2.CleanDir(xcDir)
+
+	var xcList []string
+	for _, libName := range opt.StaticLibNames {
+		xcArgs := []string{"-create-xcframework"}
+		for _, sdk := range sdks {
+			sdkDir := ku.GetSDKDir(buildTypeDir, sdk)
+			// Example: sdk-iphoneos/framework/ffmpeg/static
+			sdkLibDir := filepath.Join(ku.GetSDKFrameworkDir(sdkDir), target, "static")
+			io2.Mkdirp(sdkLibDir)
+
+			// Loop each arch and create a list of static lib paths.
+			var archLibPaths []string
+			for _, arch := range ku.SDKArchs[sdk] {
+				targetDir := filepath.Join(ku.GetSDKArchDir(sdkDir, arch), target)
+				distLibDir := goToLibDir(ku.GetTargetDistDir(targetDir), opt.LibSubDir)
+				archLibPath := filepath.Join(distLibDir, libName+".a")
+				if !io2.FileExists(archLibPath) {
+					shell.Quit("Static lib not found: " + archLibPath)
+				}
+				archLibPaths = append(archLibPaths, archLibPath)
+			}
+
+			// lipo
+			sdkLibPath := filepath.Join(sdkLibDir, libName+".a")
+			lipoArgs := []string{"-create"}
+			lipoArgs = append(lipoArgs, archLibPaths...)
+			lipoArgs = append(lipoArgs, "-output", sdkLibPath)
+			shell.Spawn(&j9.SpawnOpt{
+				Name: "lipo",
+				Args: lipoArgs,
+			})
+
+			// Headers. Use arm64 headers for the resulting lib.
+			arm64TargetDir := filepath.Join(ku.GetSDKArchDir(sdkDir, ku.ArchArm64), target)
+			srcHeadersDir := filepath.Join(ku.GetTargetDistDir(arm64TargetDir), "include")
+			if opt.IncludeSubDir != "" {
+				srcHeadersDir = filepath.Join(srcHeadersDir, opt.IncludeSubDir)
+			}
+			if opt.LibHeaderPathMap != nil && opt.LibHeaderPathMap[libName] != "" {
+				srcHeadersDir = filepath.Join(srcHeadersDir, opt.LibHeaderPathMap[libName])
+			}
+			if !io2.DirectoryExists(srcHeadersDir) {
+				shell.Quit("Headers dir not found: " + srcHeadersDir)
+			}
+			headersDir := filepath.Join(sdkLibDir, libName+"-headers")
+			io2.CleanDir(headersDir)
+			shell.Shell("cp -R " + filepath.Join(srcHeadersDir, "*") + " " + headersDir)
+
+			if moduleMapTargetLibNames[libName] {
+				moduleMapFile := filepath.Join(headersDir, "module.modulemap")
+				err := os.WriteFile(moduleMapFile, []byte(moduleMapForStaticLib(libName)), 0644)
+				if err != nil {
+					shell.Quit(fmt.Sprintf("Error writing module.modulemap at %s: %v", moduleMapFile, err))
+				}
+			}
+
+			xcArgs = append(xcArgs, "-library", sdkLibPath, "-headers", headersDir)
+		}
+
+		xcLibDir := filepath.Join(xcDir, libName+".xcframework")
+		xcArgs = append(xcArgs, "-output", xcLibDir)
+		shell.Spawn(&j9.SpawnOpt{
+			Name: "xcodebuild",
+			Args: xcArgs,
+		})
+		xcList = append(xcList, xcLibDir)
+	}
+	return xcList
+}
+
+func moduleMapForStaticLib(libName string) string {
+	return `module ` + libName + ` {
+	header "` + libName + `.h"
+
+	export *
+}`
+}
@@ -37,6 +37,11 @@ type XCBuildOptions struct {
 	// An optional subdirectory under include/ to search for headers.
 	IncludeSubDir string
 
+	// If set, create library xcframeworks from these static libs in dist lib dirs (e.g. merged by `ku.MergeStaticLibs`)
+	// instead of framework xcframeworks from dylibs.
+	// Example: libffmpeg
+	StaticLibNames []string
+
 	// Default is false. Only update dependency rpaths that are in the build directory.
 	// If true, update all dependency rpaths that are not in /usr/bin.
 	AggressiveDepRpathUpdates bool
@@ -62,4 +67,7 @@ type iFrameworkInfo struct {
 	// Could be multiple if fat.
 	SourceDylibPaths []string
 	IsFat            bool
+	// dSYMs of each arch (if debug symbols were split during the build).
+	// Example: dist/symbols/iphoneos/arm64/libavformat.61.7.100.dylib.dSYM
+	DebugSymbolPaths []string
 }
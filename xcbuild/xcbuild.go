@@ -26,9 +26,19 @@ func Build(opt *XCBuildOptions) {
 
 	cliArgs := ku.ParseCLIArgs(cliOpt)
 	shell := ku.NewShell(ku.CreateDefaultTunnel(), cliArgs)
-	buildTypeDir := ku.GetBuildTypeDir(cliArgs.DebugBuild)
+	buildTypeDir := ku.GetProfileBuildTypeDir(cliArgs.DebugBuild, cliArgs.Profile)
 	target := cliArgs.Target
 
+	// Sanitizer builds bundle the sanitizer runtime in each framework.
+	manifest, err := ku.ReadBuildManifest(buildTypeDir, target)
+	if err != nil {
+		shell.Quit(err.Error())
+	}
+	var sanitizer string
+	if manifest != nil {
+		sanitizer = manifest.Sanitizer
+	}
+
 	xcCtx := &XCContext{
 		CLIArgs: cliArgs,
 		Tunnel:  shell.Tunnel,
@@ -64,6 +74,16 @@ func Build(opt *XCBuildOptions) {
 		platformStr = "darwin"
 	}
 
+	xcRootDir := ku.GetXCFrameworkDir(buildTypeDir)
+	xcDir := filepath.Join(xcRootDir, platformStr, target)
+
+	if len(opt.StaticLibNames) > 0 {
+		xcList := buildStaticXCFrameworks(shell, opt, xcCtx, sdks, buildTypeDir, xcDir, moduleMapTargetLibNames)
+		signXCFrameworks(shell, xcList)
+		shell.Log(j9.LogLevelInfo, "🚕 XC build completed")
+		return
+	}
+
 	// K: library name
 	// V: framework info
 	// Example:
@@ -205,9 +225,25 @@ func Build(opt *XCBuildOptions) {
 				Args: lipoArgs},
 			)
 
+			// Bundle the sanitizer runtime, which is referenced by `@rpath/libclang_rt.*.dylib`.
+			// The runtime is already fat, copy the one from the first arch.
+			var fwSanitizerRuntimePath string
+			if sanitizer != "" {
+				srcRuntimePath := findSanitizerRuntime(shell, filepath.Dir(archDylibPaths[0]), sanitizer)
+				fwSanitizerRuntimePath = filepath.Join(fwContentDir, filepath.Base(srcRuntimePath))
+				shell.Spawn(&j9.SpawnOpt{
+					Name: "cp",
+					Args: []string{srcRuntimePath, fwSanitizerRuntimePath},
+				})
+				shell.Spawn(&j9.SpawnOpt{
+					Name: "install_name_tool",
+					Args: []string{"-add_rpath", "@loader_path", fwBinPath},
+				})
+			}
+
 			// Add Info.plist
 			infoPlistContent := infoPlistForFw(dylibInfo.Name, "com.mgenware", isMacos)
-			err := os.WriteFile(fwInfoPlistPath, []byte(infoPlistContent), 0644)
+			err = os.WriteFile(fwInfoPlistPath, []byte(infoPlistContent), 0644)
 			if err != nil {
 				shell.Quit(fmt.Sprintf("Error writing Info.plist at %s: %v", fwInfoPlistPath, err))
 			}
@@ -260,9 +296,21 @@ func Build(opt *XCBuildOptions) {
 				if isMacos {
 					signType = kCodeSignTypeMacFramework
 				}
+				if fwSanitizerRuntimePath != "" {
+					codeSign(shell, fwSanitizerRuntimePath, cliArgs.SignArg, signType)
+				}
 				codeSign(shell, fwBinPath, cliArgs.SignArg, signType)
 			}
 
+			// dSYMs extracted by `-split-debug` builds.
+			var debugSymbolPaths []string
+			for _, arch := range archs {
+				dsymPath := filepath.Join(ku.GetSymbolsDir(buildTypeDir, sdk, arch), dylibInfo.FileName+".dSYM")
+				if io2.DirectoryExists(dsymPath) {
+					debugSymbolPaths = append(debugSymbolPaths, dsymPath)
+				}
+			}
+
 			// Save the dylib path.
 			fwInfo := iFrameworkInfo{
 				LibInfo:          dylibInfo,
@@ -271,6 +319,7 @@ func Build(opt *XCBuildOptions) {
 				SourceHeadersDir: srcDylibHeadersDir,
 				SourceDylibPaths: archDylibPaths,
 				IsFat:            srcDylibFat,
+				DebugSymbolPaths: debugSymbolPaths,
 			}
 
 			fwMap[dylibInfo.Name] = append(fwMap[dylibInfo.Name], fwInfo)
@@ -280,8 +329,6 @@ func Build(opt *XCBuildOptions) {
 		}
 	} // end of `for sdks`.
 
-	xcRootDir := ku.GetXCFrameworkDir(buildTypeDir)
-	xcDir := filepath.Join(xcRootDir, platformStr, target)
 	io2.CleanDir(xcDir)
 	var xcList []string
 
@@ -298,6 +345,10 @@ func Build(opt *XCBuildOptions) {
 		xcArgs = append(xcArgs, "-create-xcframework")
 		for _, sdkFw := range sdkFwList {
 			xcArgs = append(xcArgs, "-framework", sdkFw.Path)
+			// `-debug-symbols` applies to the preceding `-framework`.
+			for _, dsymPath := range sdkFw.DebugSymbolPaths {
+				xcArgs = append(xcArgs, "-debug-symbols", dsymPath)
+			}
 		}
 		xcArgs = append(xcArgs, "-output")
 		xcArgs = append(xcArgs, xcLibDir)
@@ -308,17 +359,21 @@ func Build(opt *XCBuildOptions) {
 		xcList = append(xcList, xcLibDir)
 	}
 
-	// Signing.
-	if cliArgs.SignArg != "" {
-		shell.Log(j9.LogLevelWarning, "Signing xcframeworks")
-		for _, xc := range xcList {
-			codeSign(shell, xc, cliArgs.SignArg, kCodeSignTypeXCFramework)
-		}
-	}
-
+	signXCFrameworks(shell, xcList)
 	shell.Log(j9.LogLevelInfo, "🚕 XC build completed")
 }
 
+func signXCFrameworks(shell *ku.Shell, xcList []string) {
+	cliArgs := shell.Args
+	if cliArgs.SignArg == "" {
+		return
+	}
+	shell.Log(j9.LogLevelWarning, "Signing xcframeworks")
+	for _, xc := range xcList {
+		codeSign(shell, xc, cliArgs.SignArg, kCodeSignTypeXCFramework)
+	}
+}
+
 type CodeSignType string
 
 const (
@@ -365,8 +420,8 @@ func getDylibsInfo(shell *ku.Shell, libDir string, userLibs map[string]bool) []X
 		if file.IsDir() || file.Type()&fs.ModeSymlink != 0 {
 			continue
 		}
-		// Skip non dylib files.
-		if !strings.HasSuffix(fileName, ".dylib") {
+		// Skip non dylib files and bundled sanitizer runtimes.
+		if !strings.HasSuffix(fileName, ".dylib") || strings.HasPrefix(fileName, ku.SanitizerRuntimePrefix) {
 			continue
 		}
 
@@ -461,8 +516,8 @@ func updateDylibDepRpath(shell *ku.Shell, dylibPath string, buildDir string, agg
 		line = strings.TrimSpace(line)
 		if aggressiveDepRpathUpdates {
 			// Aggressive dep rpath updates.
-			// Only deps in system paths are ignored.
-			if strings.HasPrefix(line, "/usr/lib/") || strings.HasPrefix(line, "/System/Library/") {
+			// Only deps in system paths and sanitizer runtimes are ignored.
+			if strings.HasPrefix(line, "/usr/lib/") || strings.HasPrefix(line, "/System/Library/") || strings.HasPrefix(line, "@rpath/"+ku.SanitizerRuntimePrefix) {
 				continue
 			}
 		} else {
@@ -502,6 +557,14 @@ func updateDylibDepRpath(shell *ku.Shell, dylibPath string, buildDir string, agg
 	}
 }
 
+func findSanitizerRuntime(shell *ku.Shell, libDir string, sanitizer string) string {
+	matches, _ := filepath.Glob(filepath.Join(libDir, ku.SanitizerRuntimePrefix+sanitizer+"_*_dynamic.dylib"))
+	if len(matches) == 0 {
+		shell.Quit(fmt.Sprintf("Sanitizer runtime %s not found in %s", sanitizer, libDir))
+	}
+	return matches[0]
+}
+
 func trimLibPrefix(s string) string {
 	if strings.HasPrefix(s, "lib") {
 		return s[3:]